@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"database/sql"
+	"film-rental/internal/catalog/model"
+	"film-rental/internal/catalog/repository"
+	filmRepository "film-rental/internal/film/repository"
+	"film-rental/pkg/response"
+	"film-rental/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+	return page, limit
+}
+
+// validateActorFields validates all required actor fields
+func validateActorFields(req model.ActorRequest) (string, error) {
+	if err := validator.ValidateString(strings.TrimSpace(req.FirstName), 1, 45); err != nil {
+		return "First name validation failed", err
+	}
+	if err := validator.ValidateString(strings.TrimSpace(req.LastName), 1, 45); err != nil {
+		return "Last name validation failed", err
+	}
+	return "", nil
+}
+
+func GetActors(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	actors, count, err := repository.GetAllActors(page, limit, strings.TrimSpace(c.Query("search")))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get actors", err)
+		return
+	}
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), actors)
+}
+
+func GetActorDetail(c *gin.Context) {
+	actorId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid actor ID", err)
+		return
+	}
+
+	actor, err := repository.GetActorDetail(actorId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get actor detail", err)
+		return
+	}
+	if actor == nil {
+		response.WriteError(c, http.StatusNotFound, "Actor not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", actor)
+}
+
+// GetActorFilms lists the films an actor appears in
+func GetActorFilms(c *gin.Context) {
+	actorId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid actor ID", err)
+		return
+	}
+	page, limit := parsePagination(c)
+
+	films, count, err := filmRepository.GetFilmsByActor(actorId, page, limit)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get films", err)
+		return
+	}
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), films)
+}
+
+func AddActor(c *gin.Context) {
+	var req model.ActorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	message, err := validateActorFields(req)
+	if message != "" {
+		response.WriteError(c, http.StatusBadRequest, message, err)
+		return
+	}
+
+	id, err := repository.InsertActor(model.Actor{
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
+	})
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to insert actor", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id})
+}
+
+func UpdateActor(c *gin.Context) {
+	actorId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid actor ID", err)
+		return
+	}
+
+	var req model.ActorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	message, err := validateActorFields(req)
+	if message != "" {
+		response.WriteError(c, http.StatusBadRequest, message, err)
+		return
+	}
+
+	err = repository.UpdateActor(actorId, model.Actor{
+		FirstName: strings.TrimSpace(req.FirstName),
+		LastName:  strings.TrimSpace(req.LastName),
+	})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Actor not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update actor", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Actor updated successfully", nil)
+}
+
+func DeleteActor(c *gin.Context) {
+	actorId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid actor ID", err)
+		return
+	}
+
+	err = repository.DeleteActor(actorId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Actor not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to delete actor", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Actor deleted successfully", nil)
+}
@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"database/sql"
+	"film-rental/internal/catalog/model"
+	"film-rental/internal/catalog/repository"
+	filmRepository "film-rental/internal/film/repository"
+	"film-rental/pkg/response"
+	"film-rental/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetCategories(c *gin.Context) {
+	categories, err := repository.GetAllCategories()
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get categories", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Success", categories)
+}
+
+func GetCategoryDetail(c *gin.Context) {
+	categoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	category, err := repository.GetCategoryDetail(categoryId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get category detail", err)
+		return
+	}
+	if category == nil {
+		response.WriteError(c, http.StatusNotFound, "Category not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", category)
+}
+
+// GetCategoryFilms lists the films in a category
+func GetCategoryFilms(c *gin.Context) {
+	categoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+	page, limit := parsePagination(c)
+
+	films, count, err := filmRepository.GetFilmsByCategory(categoryId, page, limit)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get films", err)
+		return
+	}
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), films)
+}
+
+func AddCategory(c *gin.Context) {
+	var req model.NameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := validator.ValidateString(name, 1, 25); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Name validation failed", err)
+		return
+	}
+
+	id, err := repository.InsertCategory(name)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to insert category", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id})
+}
+
+func UpdateCategory(c *gin.Context) {
+	categoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	var req model.NameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := validator.ValidateString(name, 1, 25); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Name validation failed", err)
+		return
+	}
+
+	err = repository.UpdateCategory(categoryId, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Category not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update category", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Category updated successfully", nil)
+}
+
+func DeleteCategory(c *gin.Context) {
+	categoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid category ID", err)
+		return
+	}
+
+	err = repository.DeleteCategory(categoryId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Category not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to delete category", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Category deleted successfully", nil)
+}
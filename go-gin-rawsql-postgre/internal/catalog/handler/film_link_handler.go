@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/catalog/repository"
+	"film-rental/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseLinkIds reads the film id and the linked resource id from the route
+func parseLinkIds(c *gin.Context, linkParam string) (int, int, bool) {
+	filmId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid film ID", err)
+		return 0, 0, false
+	}
+	linkId, err := strconv.Atoi(c.Param(linkParam))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid "+linkParam, err)
+		return 0, 0, false
+	}
+	return filmId, linkId, true
+}
+
+func writeLinkResult(c *gin.Context, err error, message string) {
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			response.WriteError(c, http.StatusNotFound, "Link not found", err)
+		case errors.Is(err, repository.ErrNotFound):
+			response.WriteError(c, http.StatusNotFound, "Film or linked resource not found", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to update film link", err)
+		}
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, message, nil)
+}
+
+func LinkFilmCategory(c *gin.Context) {
+	filmId, categoryId, ok := parseLinkIds(c, "category_id")
+	if !ok {
+		return
+	}
+	writeLinkResult(c, repository.LinkFilmCategory(filmId, categoryId), "Film added to category")
+}
+
+func UnlinkFilmCategory(c *gin.Context) {
+	filmId, categoryId, ok := parseLinkIds(c, "category_id")
+	if !ok {
+		return
+	}
+	writeLinkResult(c, repository.UnlinkFilmCategory(filmId, categoryId), "Film removed from category")
+}
+
+func LinkFilmActor(c *gin.Context) {
+	filmId, actorId, ok := parseLinkIds(c, "actor_id")
+	if !ok {
+		return
+	}
+	writeLinkResult(c, repository.LinkFilmActor(filmId, actorId), "Actor added to film")
+}
+
+func UnlinkFilmActor(c *gin.Context) {
+	filmId, actorId, ok := parseLinkIds(c, "actor_id")
+	if !ok {
+		return
+	}
+	writeLinkResult(c, repository.UnlinkFilmActor(filmId, actorId), "Actor removed from film")
+}
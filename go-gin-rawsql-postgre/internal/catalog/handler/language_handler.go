@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/catalog/model"
+	"film-rental/internal/catalog/repository"
+	"film-rental/pkg/response"
+	"film-rental/validator"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetLanguages(c *gin.Context) {
+	languages, err := repository.GetAllLanguages()
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get languages", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Success", languages)
+}
+
+func GetLanguageDetail(c *gin.Context) {
+	languageId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid language ID", err)
+		return
+	}
+
+	language, err := repository.GetLanguageDetail(languageId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get language detail", err)
+		return
+	}
+	if language == nil {
+		response.WriteError(c, http.StatusNotFound, "Language not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", language)
+}
+
+func AddLanguage(c *gin.Context) {
+	var req model.NameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := validator.ValidateString(name, 1, 20); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Name validation failed", err)
+		return
+	}
+
+	id, err := repository.InsertLanguage(name)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to insert language", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id})
+}
+
+func UpdateLanguage(c *gin.Context) {
+	languageId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid language ID", err)
+		return
+	}
+
+	var req model.NameRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if err := validator.ValidateString(name, 1, 20); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Name validation failed", err)
+		return
+	}
+
+	err = repository.UpdateLanguage(languageId, name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Language not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update language", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Language updated successfully", nil)
+}
+
+func DeleteLanguage(c *gin.Context) {
+	languageId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid language ID", err)
+		return
+	}
+
+	err = repository.DeleteLanguage(languageId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Language not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrInUse) {
+			response.WriteError(c, http.StatusConflict, "Language is still used by films", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to delete language", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Language deleted successfully", nil)
+}
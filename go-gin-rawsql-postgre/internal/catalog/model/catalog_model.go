@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+type Language struct {
+	LanguageId int       `json:"language_id"`
+	Name       string    `json:"name"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+type Category struct {
+	CategoryId int       `json:"category_id"`
+	Name       string    `json:"name"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+type Actor struct {
+	ActorId    int       `json:"actor_id"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+// NameRequest is used for creating and updating languages and categories
+type NameRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ActorRequest is used for creating and updating actors
+type ActorRequest struct {
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+}
@@ -0,0 +1,157 @@
+package repository
+
+import (
+	"database/sql"
+	"film-rental/internal/catalog/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"fmt"
+	"time"
+)
+
+const actorColumns = "actor_id, first_name, last_name, last_update"
+
+func scanActorRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Actor, error) {
+	var a model.Actor
+	err := scanner.Scan(&a.ActorId, &a.FirstName, &a.LastName, &a.LastUpdate)
+	return &a, err
+}
+
+// GetAllActors returns a page of actors, optionally filtered by a case-insensitive name search
+func GetAllActors(page int, limit int, search string) ([]*model.Actor, int, error) {
+	where := ""
+	args := []any{}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		where = ` WHERE (first_name || ' ' || last_name) ILIKE $1`
+	}
+
+	queryStr := fmt.Sprintf(`SELECT %s FROM actor%s ORDER BY last_name, first_name, actor_id LIMIT $%d OFFSET $%d`,
+		actorColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := dbRaw.DB.Query(queryStr, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM actor"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	var actors []*model.Actor
+	for rows.Next() {
+		if a, err := scanActorRow(rows); err != nil {
+			continue
+		} else {
+			actors = append(actors, a)
+		}
+	}
+
+	return actors, totalCount, nil
+}
+
+func GetActorDetail(actorId int) (*model.Actor, error) {
+	a, err := scanActorRow(dbRaw.DB.QueryRow(`SELECT `+actorColumns+` FROM actor WHERE actor_id = $1`, actorId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// GetFilmActors returns the cast of a film
+func GetFilmActors(filmId int) ([]*model.Actor, error) {
+	query := `
+		SELECT a.actor_id, a.first_name, a.last_name, a.last_update
+		FROM actor a
+		JOIN film_actor fa ON fa.actor_id = a.actor_id
+		WHERE fa.film_id = $1
+		ORDER BY a.last_name, a.first_name
+	`
+
+	rows, err := dbRaw.DB.Query(query, filmId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	actors := []*model.Actor{}
+	for rows.Next() {
+		a, err := scanActorRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		actors = append(actors, a)
+	}
+
+	return actors, rows.Err()
+}
+
+func InsertActor(actor model.Actor) (int64, error) {
+	var lastID int64
+	err := dbRaw.DB.QueryRow(`INSERT INTO actor (first_name, last_name, last_update) VALUES ($1, $2, $3) RETURNING actor_id`,
+		actor.FirstName, actor.LastName, time.Now()).Scan(&lastID)
+	if err != nil {
+		return 0, err
+	}
+	return lastID, nil
+}
+
+func UpdateActor(actorId int, actor model.Actor) error {
+	result, err := dbRaw.DB.Exec(`UPDATE actor SET first_name = $1, last_name = $2, last_update = $3 WHERE actor_id = $4`,
+		actor.FirstName, actor.LastName, time.Now(), actorId)
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
+
+// DeleteActor deletes an actor together with their film credits
+func DeleteActor(actorId int) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM film_actor WHERE actor_id = $1`, actorId); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM actor WHERE actor_id = $1`, actorId)
+	if err != nil {
+		return err
+	}
+	if err := requireRowAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LinkFilmActor adds an actor to a film's cast. Linking twice is a no-op.
+func LinkFilmActor(filmId int, actorId int) error {
+	query := `
+		INSERT INTO film_actor (actor_id, film_id, last_update)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (actor_id, film_id) DO NOTHING
+	`
+	_, err := dbRaw.DB.Exec(query, actorId, filmId, time.Now())
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func UnlinkFilmActor(filmId int, actorId int) error {
+	result, err := dbRaw.DB.Exec(`DELETE FROM film_actor WHERE actor_id = $1 AND film_id = $2`, actorId, filmId)
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
@@ -0,0 +1,97 @@
+package repository_test
+
+import (
+	"film-rental/internal/catalog/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+// TestDeleteLanguage_InUse_Mock tests that a language used by films cannot be deleted
+func TestDeleteLanguage_InUse_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	// Set the mock database to the global db.DB
+	dbRaw.DB = mockDB
+
+	mock.ExpectExec(`DELETE FROM language WHERE language_id = \$1`).
+		WithArgs(1).
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	if err := repository.DeleteLanguage(1); err != repository.ErrInUse {
+		t.Fatalf("expected ErrInUse, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestLinkFilmActor_Mock tests linking actors to films
+func TestLinkFilmActor_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	// Test case 1: link is created
+	mock.ExpectExec(`INSERT INTO film_actor`).
+		WithArgs(10, 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repository.LinkFilmActor(1, 10); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Test case 2: unknown actor
+	mock.ExpectExec(`INSERT INTO film_actor`).
+		WithArgs(999, 1, sqlmock.AnyArg()).
+		WillReturnError(&pq.Error{Code: "23503"})
+
+	if err := repository.LinkFilmActor(1, 999); err != repository.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestGetFilmActors_Mock tests loading a film's cast
+func TestGetFilmActors_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectQuery(`SELECT (.+) FROM actor a JOIN film_actor fa (.+) WHERE fa.film_id = \$1`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"actor_id", "first_name", "last_name", "last_update"}).
+			AddRow(1, "PENELOPE", "GUINESS", time.Now()).
+			AddRow(10, "CHRISTIAN", "GABLE", time.Now()))
+
+	actors, err := repository.GetFilmActors(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actors) != 2 || actors[1].LastName != "GABLE" {
+		t.Fatalf("unexpected actors: %+v", actors)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"database/sql"
+	"film-rental/internal/catalog/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+)
+
+const categoryColumns = "category_id, name, last_update"
+
+func scanCategoryRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Category, error) {
+	var c model.Category
+	err := scanner.Scan(&c.CategoryId, &c.Name, &c.LastUpdate)
+	return &c, err
+}
+
+func GetAllCategories() ([]*model.Category, error) {
+	rows, err := dbRaw.DB.Query(`SELECT ` + categoryColumns + ` FROM category ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*model.Category{}
+	for rows.Next() {
+		if c, err := scanCategoryRow(rows); err != nil {
+			continue
+		} else {
+			categories = append(categories, c)
+		}
+	}
+
+	return categories, nil
+}
+
+func GetCategoryDetail(categoryId int) (*model.Category, error) {
+	c, err := scanCategoryRow(dbRaw.DB.QueryRow(`SELECT `+categoryColumns+` FROM category WHERE category_id = $1`, categoryId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// GetFilmCategories returns the categories a film belongs to
+func GetFilmCategories(filmId int) ([]*model.Category, error) {
+	query := `
+		SELECT c.category_id, c.name, c.last_update
+		FROM category c
+		JOIN film_category fc ON fc.category_id = c.category_id
+		WHERE fc.film_id = $1
+		ORDER BY c.name
+	`
+
+	rows, err := dbRaw.DB.Query(query, filmId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := []*model.Category{}
+	for rows.Next() {
+		c, err := scanCategoryRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		categories = append(categories, c)
+	}
+
+	return categories, rows.Err()
+}
+
+func InsertCategory(name string) (int64, error) {
+	var lastID int64
+	err := dbRaw.DB.QueryRow(`INSERT INTO category (name, last_update) VALUES ($1, $2) RETURNING category_id`, name, time.Now()).Scan(&lastID)
+	if err != nil {
+		return 0, err
+	}
+	return lastID, nil
+}
+
+func UpdateCategory(categoryId int, name string) error {
+	result, err := dbRaw.DB.Exec(`UPDATE category SET name = $1, last_update = $2 WHERE category_id = $3`, name, time.Now(), categoryId)
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
+
+// DeleteCategory deletes a category together with its film links
+func DeleteCategory(categoryId int) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM film_category WHERE category_id = $1`, categoryId); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM category WHERE category_id = $1`, categoryId)
+	if err != nil {
+		return err
+	}
+	if err := requireRowAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// LinkFilmCategory adds a film to a category. Linking twice is a no-op.
+func LinkFilmCategory(filmId int, categoryId int) error {
+	query := `
+		INSERT INTO film_category (film_id, category_id, last_update)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (film_id, category_id) DO NOTHING
+	`
+	_, err := dbRaw.DB.Exec(query, filmId, categoryId, time.Now())
+	if isForeignKeyViolation(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func UnlinkFilmCategory(filmId int, categoryId int) error {
+	result, err := dbRaw.DB.Exec(`DELETE FROM film_category WHERE film_id = $1 AND category_id = $2`, filmId, categoryId)
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
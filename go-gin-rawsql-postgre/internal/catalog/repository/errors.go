@@ -0,0 +1,18 @@
+package repository
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// ErrInUse is returned when a row cannot be deleted because films still reference it
+var ErrInUse = errors.New("resource is still referenced by films")
+
+// ErrNotFound is returned when linking a film to an actor or category that does not exist
+var ErrNotFound = errors.New("film or linked resource not found")
+
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"film-rental/internal/catalog/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+)
+
+// language.name is a char(20) column, so it is trimmed when read
+const languageColumns = "language_id, TRIM(name), last_update"
+
+func scanLanguageRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Language, error) {
+	var l model.Language
+	err := scanner.Scan(&l.LanguageId, &l.Name, &l.LastUpdate)
+	return &l, err
+}
+
+func GetAllLanguages() ([]*model.Language, error) {
+	rows, err := dbRaw.DB.Query(`SELECT ` + languageColumns + ` FROM language ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	languages := []*model.Language{}
+	for rows.Next() {
+		if l, err := scanLanguageRow(rows); err != nil {
+			continue
+		} else {
+			languages = append(languages, l)
+		}
+	}
+
+	return languages, nil
+}
+
+func GetLanguageDetail(languageId int) (*model.Language, error) {
+	l, err := scanLanguageRow(dbRaw.DB.QueryRow(`SELECT `+languageColumns+` FROM language WHERE language_id = $1`, languageId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func InsertLanguage(name string) (int64, error) {
+	var lastID int64
+	err := dbRaw.DB.QueryRow(`INSERT INTO language (name, last_update) VALUES ($1, $2) RETURNING language_id`, name, time.Now()).Scan(&lastID)
+	if err != nil {
+		return 0, err
+	}
+	return lastID, nil
+}
+
+func UpdateLanguage(languageId int, name string) error {
+	result, err := dbRaw.DB.Exec(`UPDATE language SET name = $1, last_update = $2 WHERE language_id = $3`, name, time.Now(), languageId)
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
+
+// DeleteLanguage deletes a language. It returns ErrInUse while films are still in that language.
+func DeleteLanguage(languageId int) error {
+	result, err := dbRaw.DB.Exec(`DELETE FROM language WHERE language_id = $1`, languageId)
+	if isForeignKeyViolation(err) {
+		return ErrInUse
+	}
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
+
+func requireRowAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"database/sql"
+	"film-rental/internal/customer/model"
+	"film-rental/internal/customer/repository"
+	"film-rental/pkg/response"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// validateCustomerFields validates all required customer fields
+func validateCustomerFields(req model.CustomerRequest) (string, error) {
+	if strings.TrimSpace(req.FirstName) == "" {
+		return "First name is required", nil
+	}
+	if strings.TrimSpace(req.LastName) == "" {
+		return "Last name is required", nil
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		return "Invalid email address", err
+	}
+	if req.StoreId <= 0 {
+		return "Store ID must be greater than 0", nil
+	}
+	if req.AddressId <= 0 {
+		return "Address ID must be greater than 0", nil
+	}
+	return "", nil
+}
+
+func GetCustomers(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+
+	customers, count, err := repository.GetAllCustomers(page, limit, strings.TrimSpace(c.Query("search")))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get customers", err)
+		return
+	}
+
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), customers)
+}
+
+func GetCustomerDetail(c *gin.Context) {
+	customerId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	customer, err := repository.GetCustomerDetail(customerId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get customer detail", err)
+		return
+	}
+	if customer == nil {
+		response.WriteError(c, http.StatusNotFound, "Customer not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", customer)
+}
+
+func AddCustomer(c *gin.Context) {
+	var req model.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	message, err := validateCustomerFields(req)
+	if message != "" {
+		response.WriteError(c, http.StatusBadRequest, message, err)
+		return
+	}
+
+	customer := model.Customer{
+		StoreId:    req.StoreId,
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Email:      req.Email,
+		AddressId:  req.AddressId,
+		LastUpdate: time.Now(),
+	}
+
+	id, err := repository.InsertCustomer(customer)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to insert customer", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id})
+}
+
+func UpdateCustomer(c *gin.Context) {
+	customerId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	var req model.CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	message, err := validateCustomerFields(req)
+	if message != "" {
+		response.WriteError(c, http.StatusBadRequest, message, err)
+		return
+	}
+
+	customer := model.Customer{
+		StoreId:    req.StoreId,
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		Email:      req.Email,
+		AddressId:  req.AddressId,
+		LastUpdate: time.Now(),
+	}
+
+	err = repository.UpdateCustomer(customerId, customer)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Customer not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update customer", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Customer updated successfully", nil)
+}
+
+func DeactivateCustomer(c *gin.Context) {
+	customerId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	err = repository.DeactivateCustomer(customerId)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Customer not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to deactivate customer", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Customer deactivated successfully", nil)
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+type Customer struct {
+	CustomerId int       `json:"customer_id"`
+	StoreId    int       `json:"store_id"`
+	FirstName  string    `json:"first_name"`
+	LastName   string    `json:"last_name"`
+	Email      string    `json:"email"`
+	AddressId  int       `json:"address_id"`
+	Active     bool      `json:"active"`
+	CreateDate time.Time `json:"create_date"`
+	LastUpdate time.Time `json:"last_update"`
+}
+
+// CustomerRequest is used for creating and updating customers
+type CustomerRequest struct {
+	StoreId   int    `json:"store_id" binding:"required"`
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+	Email     string `json:"email" binding:"required"`
+	AddressId int    `json:"address_id" binding:"required"`
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"database/sql"
+	"film-rental/internal/customer/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"fmt"
+	"time"
+)
+
+const queryColumns = "customer_id, store_id, first_name, last_name, email, address_id, activebool, create_date, last_update"
+
+func scanCustomerRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Customer, error) {
+	var c model.Customer
+	var email sql.NullString
+	err := scanner.Scan(
+		&c.CustomerId, &c.StoreId, &c.FirstName, &c.LastName,
+		&email, &c.AddressId, &c.Active, &c.CreateDate, &c.LastUpdate,
+	)
+	c.Email = email.String
+	return &c, err
+}
+
+// GetAllCustomers returns a page of customers. When search is not empty only customers
+// whose first name, last name or email contain it (case-insensitive) are returned.
+func GetAllCustomers(page int, limit int, search string) ([]*model.Customer, int, error) {
+	where := ""
+	args := []any{}
+	if search != "" {
+		args = append(args, "%"+search+"%")
+		where = ` WHERE first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1 OR (first_name || ' ' || last_name) ILIKE $1`
+	}
+
+	queryStr := fmt.Sprintf(`SELECT %s FROM customer%s ORDER BY customer_id DESC LIMIT $%d OFFSET $%d`,
+		queryColumns, where, len(args)+1, len(args)+2)
+
+	rows, err := dbRaw.DB.Query(queryStr, append(args, limit, (page-1)*limit)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM customer"+where, args...).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	var customers []*model.Customer
+	for rows.Next() {
+		if c, err := scanCustomerRow(rows); err != nil {
+			continue
+		} else {
+			customers = append(customers, c)
+		}
+	}
+
+	return customers, totalCount, nil
+}
+
+func GetCustomerDetail(customerId int) (*model.Customer, error) {
+	queryStr := fmt.Sprintf(`SELECT %s FROM customer WHERE customer_id = $1`, queryColumns)
+
+	c, err := scanCustomerRow(dbRaw.DB.QueryRow(queryStr, customerId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func InsertCustomer(customer model.Customer) (int64, error) {
+	query := `
+		INSERT INTO customer (
+			store_id, first_name, last_name, email, address_id, activebool, create_date, last_update, active
+		) VALUES  ($1, $2, $3, $4, $5, true, $6, $6, 1)
+	    RETURNING customer_id
+	`
+
+	var lastID int64
+	err := dbRaw.DB.QueryRow(query,
+		customer.StoreId,
+		customer.FirstName,
+		customer.LastName,
+		customer.Email,
+		customer.AddressId,
+		customer.LastUpdate,
+	).Scan(&lastID)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return lastID, nil
+}
+
+func UpdateCustomer(customerId int, customer model.Customer) error {
+	query := `
+		UPDATE customer SET 
+			store_id = $1, first_name = $2, last_name = $3,
+			email = $4, address_id = $5, last_update = $6
+		WHERE customer_id = $7
+	`
+
+	result, err := dbRaw.DB.Exec(query,
+		customer.StoreId,
+		customer.FirstName,
+		customer.LastName,
+		customer.Email,
+		customer.AddressId,
+		customer.LastUpdate,
+		customerId,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// DeactivateCustomer marks a customer inactive instead of deleting it, so their
+// rental and payment history is kept.
+func DeactivateCustomer(customerId int) error {
+	query := `UPDATE customer SET activebool = false, active = 0, last_update = $1 WHERE customer_id = $2`
+
+	result, err := dbRaw.DB.Exec(query, time.Now(), customerId)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package repository_test
+
+import (
+	"database/sql"
+	"film-rental/internal/customer/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestGetAllCustomers_Search_Mock tests that the search term filters both the page and the count
+func TestGetAllCustomers_Search_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	// Set the mock database to the global db.DB
+	dbRaw.DB = mockDB
+
+	now := time.Now()
+	rows := sqlmock.NewRows([]string{"customer_id", "store_id", "first_name", "last_name", "email", "address_id", "activebool", "create_date", "last_update"}).
+		AddRow(1, 1, "Mary", "Smith", "mary.smith@example.org", 5, true, now, now)
+
+	mock.ExpectQuery(`SELECT (.+) FROM customer WHERE first_name ILIKE \$1 (.+) LIMIT \$2 OFFSET \$3`).
+		WithArgs("%mary%", 25, 0).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT \(\*\) FROM customer WHERE first_name ILIKE \$1`).
+		WithArgs("%mary%").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	customers, count, err := repository.GetAllCustomers(1, 25, "mary")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 1 || len(customers) != 1 {
+		t.Fatalf("expected 1 customer, got %d (count %d)", len(customers), count)
+	}
+	if customers[0].Email != "mary.smith@example.org" {
+		t.Fatalf("unexpected email %s", customers[0].Email)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestDeactivateCustomer_Mock tests soft deactivation of a customer
+func TestDeactivateCustomer_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectExec(`UPDATE customer SET activebool = false`).
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE customer SET activebool = false`).
+		WithArgs(sqlmock.AnyArg(), 404).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repository.DeactivateCustomer(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repository.DeactivateCustomer(404); err != sql.ErrNoRows {
+		t.Fatalf("expected sql.ErrNoRows, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"film-rental/internal/event/model"
+	"film-rental/pkg/kafka"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/response"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxDeadLetterLimit = 100
+
+// GetDeadLetters lists messages of one partition of the consumer's DLQ from ?offset=, with
+// their error, attempt count and original position
+func GetDeadLetters(c *gin.Context) {
+	partition, err := strconv.Atoi(c.DefaultQuery("partition", "0"))
+	if err != nil || partition < 0 {
+		response.WriteError(c, http.StatusBadRequest, "Invalid partition", err)
+		return
+	}
+	offset, err := strconv.ParseInt(c.DefaultQuery("offset", "0"), 10, 64)
+	if err != nil || offset < 0 {
+		response.WriteError(c, http.StatusBadRequest, "Invalid offset", err)
+		return
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+	limit = min(limit, maxDeadLetterLimit)
+
+	msgs, next, err := kafka.ReadDeadLetters(c.Request.Context(), kafka.DeadLetterTopic(), partition, offset, limit)
+	if err != nil {
+		response.WriteError(c, http.StatusBadGateway, "Failed to read dead letters", err)
+		return
+	}
+
+	page := model.DeadLetterPage{Partition: partition, NextOffset: next, Messages: make([]kafka.DeadLetter, 0, len(msgs))}
+	for _, msg := range msgs {
+		page.Messages = append(page.Messages, kafka.DescribeDeadLetter(msg))
+	}
+	response.WriteSuccess(c, http.StatusOK, "Success", page)
+}
+
+// ReplayDeadLetters publishes DLQ messages back onto their original topic. They stay in the DLQ,
+// so replaying one twice delivers it twice.
+func ReplayDeadLetters(c *gin.Context) {
+	var req model.ReplayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	result := model.ReplayResult{Replayed: []int64{}, Missing: []int64{}}
+	actor := middleware.AuthUsername(c)
+	topic := kafka.DeadLetterTopic()
+	for _, offset := range req.Offsets {
+		_, err := kafka.ReplayDeadLetter(c.Request.Context(), topic, req.Partition, offset, actor)
+		if errors.Is(err, kafka.ErrDeadLetterNotFound) {
+			result.Missing = append(result.Missing, offset)
+			continue
+		}
+		if err != nil {
+			response.WriteError(c, http.StatusBadGateway, "Failed to replay dead letters", err)
+			return
+		}
+		result.Replayed = append(result.Replayed, offset)
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Dead letters replayed", result)
+}
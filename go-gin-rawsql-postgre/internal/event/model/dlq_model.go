@@ -0,0 +1,22 @@
+package model
+
+import "film-rental/pkg/kafka"
+
+// DeadLetterPage is a page of DLQ messages. NextOffset continues the listing.
+type DeadLetterPage struct {
+	Partition  int                `json:"partition"`
+	NextOffset int64              `json:"next_offset"`
+	Messages   []kafka.DeadLetter `json:"messages"`
+}
+
+// ReplayRequest names the DLQ messages of a partition to publish again
+type ReplayRequest struct {
+	Partition int     `json:"partition"`
+	Offsets   []int64 `json:"offsets" binding:"required,min=1,max=100"`
+}
+
+// ReplayResult lists the offsets that were replayed and those not found in the DLQ
+type ReplayResult struct {
+	Replayed []int64 `json:"replayed"`
+	Missing  []int64 `json:"missing"`
+}
@@ -3,16 +3,20 @@ package handler
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
+	catalogRepository "film-rental/internal/catalog/repository"
 	"film-rental/internal/film/model"
 	"film-rental/internal/film/repository"
-	"film-rental/pkg/kafka"
+	inventoryRepository "film-rental/internal/inventory/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/pagination"
 	"film-rental/pkg/redis"
 	"film-rental/pkg/response"
 	"fmt"
 	"log"
-	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -47,33 +51,147 @@ func validateFilmFields(film model.Film) (string, error) {
 	return "", nil
 }
 
-func GetFilms(c *gin.Context) {
-	log.Println("GET /films called")
+// parseFilmFilter reads the search, filter, sort and pagination query parameters of GET /films
+func parseFilmFilter(c *gin.Context) (model.FilmFilter, string, error) {
+	filter := model.FilmFilter{
+		Search: strings.TrimSpace(c.Query("search")),
+		SortBy: c.Query("sort_by"),
+	}
+
 	page, err := strconv.Atoi(c.Query("page"))
-	if err != nil {
+	if err != nil || page < 1 {
 		page = 1
-
 	}
 	limit, err := strconv.Atoi(c.Query("limit"))
-	if err != nil {
+	if err != nil || limit < 1 {
 		limit = 25
 	}
+	filter.Page = page
+	filter.Limit = limit
 
-	films, count, err := repository.GetAllFilms(page, limit)
-	pageCount := math.Ceil(float64(count) / float64(limit))
+	if ratings := c.Query("rating"); ratings != "" {
+		for _, rating := range strings.Split(ratings, ",") {
+			rating = strings.ToUpper(strings.TrimSpace(rating))
+			if !model.ValidRatings[rating] {
+				return filter, "Invalid rating. Must be one of G, PG, PG-13, R, NC-17", nil
+			}
+			filter.Ratings = append(filter.Ratings, rating)
+		}
+	}
 
-	pagination := response.PaginationMeta{
-		Limit:      limit,
-		Page:       page,
-		TotalCount: count,
-		TotalPage:  int(pageCount),
+	intParams := []struct {
+		name  string
+		value *int
+	}{
+		{"release_year_from", &filter.ReleaseYearFrom},
+		{"release_year_to", &filter.ReleaseYearTo},
+		{"language_id", &filter.LanguageId},
+		{"length_min", &filter.LengthMin},
+		{"length_max", &filter.LengthMax},
 	}
+	for _, p := range intParams {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return filter, fmt.Sprintf("Invalid %s", p.name), err
+			}
+			*p.value = n
+		}
+	}
+
+	floatParams := []struct {
+		name  string
+		value *float64
+	}{
+		{"rental_rate_min", &filter.RentalRateMin},
+		{"rental_rate_max", &filter.RentalRateMax},
+	}
+	for _, p := range floatParams {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || n < 0 {
+				return filter, fmt.Sprintf("Invalid %s", p.name), err
+			}
+			*p.value = n
+		}
+	}
+
+	if filter.SortBy != "" {
+		if _, ok := repository.SortColumns[filter.SortBy]; !ok {
+			return filter, "Invalid sort_by", nil
+		}
+		if filter.SortBy == "relevance" && filter.Search == "" {
+			return filter, "sort_by=relevance requires a search term", nil
+		}
+	}
+	// The most relevant matches come first unless asked otherwise
+	defaultOrder := "asc"
+	if filter.SortBy == "relevance" {
+		defaultOrder = "desc"
+	}
+	switch strings.ToLower(c.DefaultQuery("sort_order", defaultOrder)) {
+	case "asc":
+	case "desc":
+		filter.SortDesc = true
+	default:
+		return filter, "Invalid sort_order. Must be 'asc' or 'desc'", nil
+	}
+
+	return filter, "", nil
+}
+
+// GetFilms lists films with offset pagination, or with keyset pagination when a
+// cursor query parameter is present (empty for the first page). In keyset mode
+// count=false skips the total count.
+func GetFilms(c *gin.Context) {
+	log.Println("GET /films called")
+	filter, message, err := parseFilmFilter(c)
+	if message != "" {
+		response.WriteError(c, http.StatusBadRequest, message, err)
+		return
+	}
+
+	if cursorToken, keyset := c.GetQuery("cursor"); keyset {
+		getFilmsByCursor(c, filter, cursorToken)
+		return
+	}
+
+	films, count, err := repository.GetAllFilms(filter)
 	if err != nil {
 		response.WriteError(c, http.StatusInternalServerError, "Failed to get films", err)
 		return
 	}
 
-	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", pagination, films)
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(filter.Page, filter.Limit, count), films)
+}
+
+func getFilmsByCursor(c *gin.Context, filter model.FilmFilter, cursorToken string) {
+	var cursor *pagination.Cursor
+	if cursorToken != "" {
+		decoded, err := pagination.DecodeCursor(cursorToken)
+		if err != nil {
+			response.WriteError(c, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		cursor = decoded
+	}
+	if cursor == nil && filter.SortBy == "relevance" {
+		response.WriteError(c, http.StatusBadRequest, "sort_by=relevance is not supported with cursor pagination", nil)
+		return
+	}
+
+	withCount := c.DefaultQuery("count", "true") != "false"
+	films, nextCursor, count, err := repository.GetFilmsAfter(filter, cursor, withCount)
+	if err != nil {
+		if errors.Is(err, pagination.ErrInvalidCursor) {
+			response.WriteError(c, http.StatusBadRequest, "Invalid cursor", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get films", err)
+		return
+	}
+
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewCursorMeta(filter.Limit, nextCursor, count), films)
 }
 
 func GetFilmDetail(c *gin.Context) {
@@ -86,23 +204,65 @@ func GetFilmDetail(c *gin.Context) {
 
 	cacheKey := fmt.Sprintf("film:%d", filmId)
 	cached, err := redis.Rdb.Get(redis.Ctx, cacheKey).Result()
-	if err == nil {
-		err = json.Unmarshal([]byte(cached), &filmDetail)
-		if err == nil {
-			response.WriteSuccess(c, http.StatusOK, "Success", filmDetail)
+	if err != nil || json.Unmarshal([]byte(cached), &filmDetail) != nil || filmDetail == nil {
+		filmDetail, err = repository.GetFilmDetail(filmId)
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to get film detail", err)
 			return
 		}
+		if filmDetail == nil {
+			response.WriteError(c, http.StatusNotFound, "Film not found", nil)
+			return
+		}
+		jsonData, _ := json.Marshal(filmDetail)
+		redis.Rdb.Set(redis.Ctx, cacheKey, jsonData, 5*time.Minute)
 	}
 
-	filmDetail, err = repository.GetFilmDetail(filmId)
+	// Availability changes with every checkout and return, so it is never cached
+	availability, err := inventoryRepository.GetFilmAvailability(filmId)
 	if err != nil {
-		response.WriteError(c, http.StatusInternalServerError, "Failed to get film detail", err)
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get film availability", err)
+		return
+	}
+
+	detail := model.FilmDetail{Film: filmDetail, Availability: availability}
+	if message, err := expandFilmDetail(&detail, c.Query("expand")); message != "" {
+		status := http.StatusInternalServerError
+		if err == nil {
+			status = http.StatusBadRequest
+		}
+		response.WriteError(c, status, message, err)
 		return
 	}
-	jsonData, _ := json.Marshal(filmDetail)
-	redis.Rdb.Set(redis.Ctx, cacheKey, jsonData, 5*time.Minute)
 
-	response.WriteSuccess(c, http.StatusOK, "Success", filmDetail)
+	response.WriteSuccess(c, http.StatusOK, "Success", detail)
+}
+
+// expandFilmDetail loads the relations named in a comma-separated expand parameter
+// (language, categories, actors) into the film detail
+func expandFilmDetail(detail *model.FilmDetail, expand string) (string, error) {
+	if expand == "" {
+		return "", nil
+	}
+
+	for _, relation := range strings.Split(expand, ",") {
+		var err error
+		switch strings.TrimSpace(relation) {
+		case "language":
+			detail.Language, err = catalogRepository.GetLanguageDetail(detail.LanguageId)
+		case "categories":
+			detail.Categories, err = catalogRepository.GetFilmCategories(detail.ID)
+		case "actors":
+			detail.Actors, err = catalogRepository.GetFilmActors(detail.ID)
+		default:
+			return "Invalid expand. Must be a list of 'language', 'categories' or 'actors'", nil
+		}
+		if err != nil {
+			return "Failed to expand " + relation, err
+		}
+	}
+
+	return "", nil
 }
 
 func AddFilm(c *gin.Context) {
@@ -118,14 +278,11 @@ func AddFilm(c *gin.Context) {
 		return
 	}
 
-	id, err := repository.InsertFilm(film)
+	id, err := repository.InsertFilm(film, middleware.AuthUsername(c))
 	if err != nil {
 		response.WriteError(c, http.StatusInternalServerError, "Failed to insert film", err)
 		return
 	}
-	// Publish Kafka event (non-blocking)
-	msg := fmt.Sprintf("Film added: %s at %s", film.Title, time.Now())
-	kafka.PublishFilmEvent(msg)
 
 	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id})
 }
@@ -149,7 +306,7 @@ func UpdateFilm(c *gin.Context) {
 		return
 	}
 
-	err = repository.UpdateFilm(filmId, film)
+	err = repository.UpdateFilm(filmId, film, middleware.AuthUsername(c))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			response.WriteError(c, http.StatusNotFound, "Film not found", err)
@@ -158,9 +315,6 @@ func UpdateFilm(c *gin.Context) {
 		response.WriteError(c, http.StatusInternalServerError, "Failed to update film", err)
 		return
 	}
-	// Publish Kafka event (non-blocking)
-	msg := fmt.Sprintf("Film updated: ID=%d at %s", film.ID, time.Now())
-	kafka.PublishFilmEvent(msg)
 	response.WriteSuccess(c, http.StatusOK, "Film updated successfully", nil)
 }
 
@@ -171,7 +325,7 @@ func DeleteFilm(c *gin.Context) {
 		return
 	}
 
-	err = repository.DeleteFilm(filmId)
+	err = repository.DeleteFilm(filmId, middleware.AuthUsername(c))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			response.WriteError(c, http.StatusNotFound, "Film not found", err)
@@ -7,6 +7,8 @@ import (
 	"film-rental/internal/token/model"
 	dbRaw "film-rental/pkg/db/raw-sql"
 	"film-rental/pkg/middleware"
+	"film-rental/pkg/pagination"
+	"film-rental/pkg/redis"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -14,6 +16,7 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/gin-gonic/gin"
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -25,6 +28,9 @@ func setupTestRouter() (*gin.Engine, *token.JWTMaker) {
 	secretKey := "12345678901234567890123456789012"
 	jwtMaker, _ := token.NewJWTMaker(secretKey)
 
+	// Point the film cache at an unreachable Redis so every lookup is a cache miss
+	redis.Rdb = goredis.NewClient(&goredis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+
 	// Setup router
 	router := gin.New()
 
@@ -85,8 +91,8 @@ func TestGetFilms(t *testing.T) {
 	// Set up mock expectations for GetAllFilms
 	// First query: SELECT film_id, title, description, release_year, rental_duration, rental_rate, length, replacement_cost, rating, last_update, language_id FROM film ORDER BY film_id DESC LIMIT $1 OFFSET $2
 	rows := sqlmock.NewRows([]string{"film_id", "title", "description", "release_year", "rental_duration", "rental_rate", "length", "replacement_cost", "rating", "last_update", "language_id"}).
-		AddRow(1, "Test Film 1", "Test Description 1", 2020, 3, 2.99, 120, 19.99, "PG", sqlmock.AnyArg(), 1).
-		AddRow(2, "Test Film 2", "Test Description 2", 2021, 3, 3.99, 130, 24.99, "PG-13", sqlmock.AnyArg(), 1)
+		AddRow(1, "Test Film 1", "Test Description 1", 2020, 3, 2.99, 120, 19.99, "PG", time.Now(), 1).
+		AddRow(2, "Test Film 2", "Test Description 2", 2021, 3, 3.99, 130, 24.99, "PG-13", time.Now(), 1)
 
 	mock.ExpectQuery("SELECT (.+) FROM film ORDER BY film_id DESC LIMIT").WithArgs(25, 0).WillReturnRows(rows)
 
@@ -110,6 +116,61 @@ func TestGetFilms(t *testing.T) {
 	assert.Contains(t, response, "data")
 }
 
+func TestGetFilmsSortByRelevance(t *testing.T) {
+	mock, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	rows := sqlmock.NewRows([]string{"film_id", "title", "description", "release_year", "rental_duration", "rental_rate", "length", "replacement_cost", "rating", "last_update", "language_id", "ts_rank"}).
+		AddRow(1, "Epic Film", "An epic drama", 2006, 3, 2.99, 100, 19.99, "PG", time.Now(), 1, 0.6)
+
+	// The most relevant matches come first without a sort_order
+	mock.ExpectQuery(`SELECT (.+) FROM film WHERE (.+) ORDER BY ts_rank\((.+)\) DESC, film_id DESC LIMIT`).
+		WithArgs("epic", 25, 0).WillReturnRows(rows)
+	mock.ExpectQuery("SELECT COUNT").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	router, _ := setupTestRouter()
+
+	req, err := http.NewRequest("GET", "/films?search=epic&sort_by=relevance", nil)
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"rank":0.6`)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetFilmsInvalidFilters(t *testing.T) {
+	router, _ := setupTestRouter()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "Unknown rating", query: "rating=X"},
+		{name: "Non-numeric release year", query: "release_year_from=abc"},
+		{name: "Negative rental rate", query: "rental_rate_max=-1"},
+		{name: "Unknown sort column", query: "sort_by=password"},
+		{name: "Relevance without search", query: "sort_by=relevance"},
+		{name: "Invalid sort order", query: "sort_by=title&sort_order=sideways"},
+		{name: "Cursor value of the wrong type", query: "cursor=" + pagination.Cursor{SortBy: "release_year", Value: "2006; DROP", ID: 1}.Encode()},
+		{name: "Cursor with an unknown rating", query: "cursor=" + pagination.Cursor{SortBy: "rating", Value: "X", ID: 1}.Encode()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/films?"+tt.query, nil)
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	}
+}
+
 func TestGetFilmDetail(t *testing.T) {
 	mock, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -128,8 +189,18 @@ func TestGetFilmDetail(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			setupMock: func() {
 				rows := sqlmock.NewRows([]string{"film_id", "title", "description", "release_year", "rental_duration", "rental_rate", "length", "replacement_cost", "rating", "last_update", "language_id"}).
-					AddRow(1, "Test Film 1", "Test Description 1", 2020, 3, 2.99, 120, 19.99, "PG", sqlmock.AnyArg(), 1)
+					AddRow(1, "Test Film 1", "Test Description 1", 2020, 3, 2.99, 120, 19.99, "PG", time.Now(), 1)
 				mock.ExpectQuery("SELECT (.+) FROM film WHERE film_id").WithArgs(1).WillReturnRows(rows)
+				mock.ExpectQuery("SELECT i.store_id").WithArgs(1).
+					WillReturnRows(sqlmock.NewRows([]string{"store_id", "total_copies", "rented_out"}).AddRow(1, 4, 1))
+			},
+		},
+		{
+			name:           "Unknown film ID",
+			filmID:         "404",
+			expectedStatus: http.StatusNotFound,
+			setupMock: func() {
+				mock.ExpectQuery("SELECT (.+) FROM film WHERE film_id").WithArgs(404).WillReturnRows(sqlmock.NewRows([]string{"film_id"}))
 			},
 		},
 		{
@@ -192,7 +263,10 @@ func TestAddFilmWithAuth(t *testing.T) {
 			},
 			expectedStatus: http.StatusCreated,
 			setupMock: func() {
+				mock.ExpectBegin()
 				mock.ExpectQuery("INSERT INTO film").WillReturnRows(sqlmock.NewRows([]string{"film_id"}).AddRow(1))
+				mock.ExpectExec("INSERT INTO outbox_messages").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -291,9 +365,12 @@ func TestUpdateFilmWithAuth(t *testing.T) {
 			},
 			expectedStatus: http.StatusOK,
 			setupMock: func() {
+				mock.ExpectBegin()
 				mock.ExpectExec("UPDATE film SET").
-					WithArgs("Updated Film", "Updated Description", 2024, 3, sqlmock.AnyArg(), 120, 19.99, "", "", sqlmock.AnyArg(), 1).
+					WithArgs("Updated Film", "Updated Description", 2024, 3, float32(5.99), 120, float32(19.99), "", sqlmock.AnyArg(), 1, 1).
 					WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectExec("INSERT INTO outbox_messages").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -356,7 +433,7 @@ func TestUpdateFilmWithAuth(t *testing.T) {
 				var response map[string]interface{}
 				err = json.Unmarshal(w.Body.Bytes(), &response)
 				require.NoError(t, err)
-				assert.Contains(t, response, "data")
+				assert.Equal(t, "Film updated successfully", response["message"])
 			}
 		})
 	}
@@ -383,7 +460,12 @@ func TestDeleteFilmWithAuth(t *testing.T) {
 			filmID:         "1",
 			expectedStatus: http.StatusOK,
 			setupMock: func() {
-				mock.ExpectExec("DELETE FROM film WHERE film_id").WithArgs(1).WillReturnResult(sqlmock.NewResult(1, 1))
+				rows := sqlmock.NewRows([]string{"film_id", "title", "description", "release_year", "rental_duration", "rental_rate", "length", "replacement_cost", "rating", "last_update", "language_id"}).
+					AddRow(1, "Test Film 1", "Test Description 1", 2020, 3, 2.99, 120, 19.99, "PG", time.Now(), 1)
+				mock.ExpectBegin()
+				mock.ExpectQuery("DELETE FROM film WHERE film_id (.+) RETURNING").WithArgs(1).WillReturnRows(rows)
+				mock.ExpectExec("INSERT INTO outbox_messages").WillReturnResult(sqlmock.NewResult(1, 1))
+				mock.ExpectCommit()
 			},
 		},
 		{
@@ -1,6 +1,13 @@
 package model
 
-import "time"
+import (
+	catalogModel "film-rental/internal/catalog/model"
+	inventoryModel "film-rental/internal/inventory/model"
+	"time"
+)
+
+// ValidRatings are the MPAA ratings a film can have
+var ValidRatings = map[string]bool{"G": true, "PG": true, "PG-13": true, "R": true, "NC-17": true}
 
 type Film struct {
 	ID              int       `json:"film_id"`
@@ -14,4 +21,34 @@ type Film struct {
 	Rating          string    `json:"rating"`
 	LastUpdate      time.Time `json:"last_update"`
 	LanguageId      int       `json:"language_id"`
+	// Rank is the full-text search relevance, set only on search results
+	Rank *float32 `json:"rank,omitempty"`
+}
+
+// FilmDetail is returned by GET /films/:id and adds per-store availability to the film
+// and, when requested with expand=, the film's language, categories and actors
+type FilmDetail struct {
+	*Film
+	Availability []inventoryModel.StoreAvailability `json:"availability"`
+	Language     *catalogModel.Language             `json:"language,omitempty"`
+	Categories   []*catalogModel.Category           `json:"categories,omitempty"`
+	Actors       []*catalogModel.Actor              `json:"actors,omitempty"`
+}
+
+// FilmFilter holds the search, filter and sort options for listing films.
+// Zero values mean the corresponding filter is not applied.
+type FilmFilter struct {
+	Search          string
+	Ratings         []string
+	ReleaseYearFrom int
+	ReleaseYearTo   int
+	LanguageId      int
+	LengthMin       int
+	LengthMax       int
+	RentalRateMin   float64
+	RentalRateMax   float64
+	SortBy          string
+	SortDesc        bool
+	Page            int
+	Limit           int
 }
@@ -4,7 +4,14 @@ import (
 	"database/sql"
 	"film-rental/internal/film/model"
 	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/kafka"
+	"film-rental/pkg/pagination"
 	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 )
 
 const columnQuery = "film_id, title, description, release_year, rental_duration, rental_rate, length, replacement_cost, rating, last_update, language_id"
@@ -21,30 +28,304 @@ func scanFilmRow(scanner interface {
 	return &f, err
 }
 
-func GetAllFilms(page int, limit int) ([]*model.Film, int, error) {
-	queryStr := `SELECT ` + columnQuery + ` FROM film ORDER BY film_id DESC LIMIT $1 OFFSET $2`
+// scanRankedFilmRow scans a film followed by its search rank
+func scanRankedFilmRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Film, error) {
+	var f model.Film
+	var rank float32
+	err := scanner.Scan(
+		&f.ID, &f.Title, &f.Description, &f.ReleaseYear,
+		&f.RentalDuration, &f.RentalRate, &f.Length,
+		&f.ReplacementCost, &f.Rating, &f.LastUpdate, &f.LanguageId, &rank,
+	)
+	f.Rank = &rank
+	return &f, err
+}
+
+// SortColumns maps the sortable fields accepted by GetAllFilms to their columns
+var SortColumns = map[string]string{
+	"film_id":      "film_id",
+	"title":        "title",
+	"release_year": "release_year",
+	"length":       "length",
+	"rental_rate":  "rental_rate",
+	"rating":       "rating",
+	"last_update":  "last_update",
+	// The search term is always the first argument when searching
+	"relevance": "ts_rank(fulltext, websearch_to_tsquery('english', $1))",
+}
+
+// buildFilmFilter turns a FilmFilter into a WHERE clause and its arguments.
+// Full-text search runs against the film.fulltext tsvector column, which covers
+// title and description and is backed by a GIN index.
+func buildFilmFilter(filter model.FilmFilter) (string, []any) {
+	var conditions []string
+	var args []any
+	addCondition := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.Search != "" {
+		addCondition("fulltext @@ websearch_to_tsquery('english', $%d)", filter.Search)
+	}
+	if len(filter.Ratings) > 0 {
+		addCondition("rating::text = ANY($%d)", pq.Array(filter.Ratings))
+	}
+	if filter.ReleaseYearFrom > 0 {
+		addCondition("release_year >= $%d", filter.ReleaseYearFrom)
+	}
+	if filter.ReleaseYearTo > 0 {
+		addCondition("release_year <= $%d", filter.ReleaseYearTo)
+	}
+	if filter.LanguageId > 0 {
+		addCondition("language_id = $%d", filter.LanguageId)
+	}
+	if filter.LengthMin > 0 {
+		addCondition("length >= $%d", filter.LengthMin)
+	}
+	if filter.LengthMax > 0 {
+		addCondition("length <= $%d", filter.LengthMax)
+	}
+	if filter.RentalRateMin > 0 {
+		addCondition("rental_rate >= $%d", filter.RentalRateMin)
+	}
+	if filter.RentalRateMax > 0 {
+		addCondition("rental_rate <= $%d", filter.RentalRateMax)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// GetAllFilms returns a page of films matching the filter together with the total
+// number of matches. Search results are ordered by relevance unless another sort is
+// given, and carry their rank.
+func GetAllFilms(filter model.FilmFilter) ([]*model.Film, int, error) {
+	where, args := buildFilmFilter(filter)
+
+	columns, scan := columnQuery, scanFilmRow
+	if filter.Search != "" {
+		columns, scan = columnQuery+", "+SortColumns["relevance"], scanRankedFilmRow
+	}
+
+	orderBy := "film_id DESC"
+	if filter.Search != "" {
+		orderBy = SortColumns["relevance"] + " DESC, film_id DESC"
+	}
+	if column, ok := SortColumns[filter.SortBy]; ok && (filter.SortBy != "relevance" || filter.Search != "") {
+		direction := "ASC"
+		if filter.SortDesc {
+			direction = "DESC"
+		}
+		orderBy = fmt.Sprintf("%s %s, film_id %s", column, direction, direction)
+	}
+
+	queryStr := fmt.Sprintf(`SELECT %s FROM film%s ORDER BY %s LIMIT $%d OFFSET $%d`,
+		columns, where, orderBy, len(args)+1, len(args)+2)
 
-	rows, err := dbRaw.DB.Query(queryStr, limit, (page-1)*limit)
+	rows, err := dbRaw.DB.Query(queryStr, append(args, filter.Limit, (filter.Page-1)*filter.Limit)...)
 
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	rowCount := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM film")
+	rowCount := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM film"+where, args...)
 
 	var totalCount int
 	if err := rowCount.Scan(&totalCount); err != nil {
 		return nil, 0, err
 	}
 
+	var films []*model.Film
+	for rows.Next() {
+		if f, err := scan(rows); err != nil {
+			continue
+		} else {
+			films = append(films, f)
+
+		}
+	}
+
+	return films, totalCount, nil
+}
+
+// filmSortValue returns the value a film has in the given sort column
+func filmSortValue(f *model.Film, sortBy string) any {
+	switch sortBy {
+	case "title":
+		return f.Title
+	case "release_year":
+		return f.ReleaseYear
+	case "length":
+		return f.Length
+	case "rental_rate":
+		return f.RentalRate
+	case "rating":
+		return f.Rating
+	case "last_update":
+		return f.LastUpdate
+	}
+	return nil
+}
+
+// cursorSortValue converts the value of a decoded cursor to the type of its sort column.
+// Cursors come from clients, so a value of another type is an invalid cursor rather
+// than a query error.
+func cursorSortValue(sortBy string, value any) (any, error) {
+	switch sortBy {
+	case "title":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case "rating":
+		if v, ok := value.(string); ok && model.ValidRatings[v] {
+			return v, nil
+		}
+	case "release_year", "length":
+		if v, ok := value.(float64); ok && v == math.Trunc(v) && math.Abs(v) <= math.MaxInt32 {
+			return int(v), nil
+		}
+	case "rental_rate":
+		if v, ok := value.(float64); ok {
+			return v, nil
+		}
+	case "last_update":
+		if v, ok := value.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return nil, pagination.ErrInvalidCursor
+}
+
+// GetFilmsAfter returns the films following the cursor using keyset pagination, which
+// stays fast on deep pages and does not skip or repeat rows when films are inserted
+// between requests. Without a cursor it returns the first page. The cursor carries its
+// own sort, so only the filters need to be sent again. The returned next cursor is
+// empty on the last page and the total is only counted when withCount is set.
+// Relevance ordering is not supported.
+func GetFilmsAfter(filter model.FilmFilter, cursor *pagination.Cursor, withCount bool) ([]*model.Film, string, *int, error) {
+	sortBy, desc := filter.SortBy, filter.SortDesc
+	if sortBy == "" {
+		sortBy, desc = "film_id", true
+	}
+	if cursor != nil {
+		sortBy, desc = cursor.SortBy, cursor.Desc
+	}
+	column, ok := SortColumns[sortBy]
+	if !ok || sortBy == "relevance" {
+		return nil, "", nil, pagination.ErrInvalidCursor
+	}
+
+	where, args := buildFilmFilter(filter)
+	countWhere, countArgs := where, args
+
+	direction, comparator := "ASC", ">"
+	if desc {
+		direction, comparator = "DESC", "<"
+	}
+
+	if cursor != nil {
+		var keyCondition string
+		if sortBy == "film_id" {
+			args = append(args, cursor.ID)
+			keyCondition = fmt.Sprintf("film_id %s $%d", comparator, len(args))
+		} else {
+			value, err := cursorSortValue(sortBy, cursor.Value)
+			if err != nil {
+				return nil, "", nil, err
+			}
+			args = append(args, value, cursor.ID)
+			keyCondition = fmt.Sprintf("(%s, film_id) %s ($%d, $%d)", column, comparator, len(args)-1, len(args))
+		}
+		if where == "" {
+			where = " WHERE " + keyCondition
+		} else {
+			where += " AND " + keyCondition
+		}
+	}
+
+	orderBy := fmt.Sprintf("film_id %s", direction)
+	if sortBy != "film_id" {
+		orderBy = fmt.Sprintf("%s %s, film_id %s", column, direction, direction)
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	queryStr := fmt.Sprintf(`SELECT %s FROM film%s ORDER BY %s LIMIT $%d`, columnQuery, where, orderBy, len(args)+1)
+
+	rows, err := dbRaw.DB.Query(queryStr, append(args, filter.Limit+1)...)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer rows.Close()
+
 	var films []*model.Film
 	for rows.Next() {
 		if f, err := scanFilmRow(rows); err != nil {
 			continue
 		} else {
 			films = append(films, f)
+		}
+	}
+
+	nextCursor := ""
+	if len(films) > filter.Limit {
+		films = films[:filter.Limit]
+		last := films[len(films)-1]
+		nextCursor = pagination.Cursor{SortBy: sortBy, Desc: desc, Value: filmSortValue(last, sortBy), ID: last.ID}.Encode()
+	}
+
+	if !withCount {
+		return films, nextCursor, nil, nil
+	}
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM film"+countWhere, countArgs...).Scan(&totalCount); err != nil {
+		return nil, "", nil, err
+	}
+
+	return films, nextCursor, &totalCount, nil
+}
+
+// GetFilmsByActor returns a page of the films an actor appears in
+func GetFilmsByActor(actorId int, page int, limit int) ([]*model.Film, int, error) {
+	return getLinkedFilms("film_actor", "actor_id", actorId, page, limit)
+}
+
+// GetFilmsByCategory returns a page of the films in a category
+func GetFilmsByCategory(categoryId int, page int, limit int) ([]*model.Film, int, error) {
+	return getLinkedFilms("film_category", "category_id", categoryId, page, limit)
+}
+
+// getLinkedFilms pages through the films linked to an id in a film_* join table.
+// Table and column names come from the callers above, never from user input.
+func getLinkedFilms(linkTable string, linkColumn string, linkId int, page int, limit int) ([]*model.Film, int, error) {
+	where := fmt.Sprintf(` WHERE film_id IN (SELECT film_id FROM %s WHERE %s = $1)`, linkTable, linkColumn)
+	queryStr := `SELECT ` + columnQuery + ` FROM film` + where + ` ORDER BY title, film_id LIMIT $2 OFFSET $3`
+
+	rows, err := dbRaw.DB.Query(queryStr, linkId, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM film"+where, linkId).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
 
+	var films []*model.Film
+	for rows.Next() {
+		if f, err := scanFilmRow(rows); err != nil {
+			continue
+		} else {
+			films = append(films, f)
 		}
 	}
 
@@ -64,7 +345,8 @@ func GetFilmDetail(filmId int) (*model.Film, error) {
 	return f, nil
 }
 
-func InsertFilm(film model.Film) (int64, error) {
+// InsertFilm creates a film and enqueues its film.created event in the same transaction
+func InsertFilm(film model.Film, actor string) (int64, error) {
 	query := `
 		INSERT INTO film (
 			title, description, release_year,
@@ -74,8 +356,14 @@ func InsertFilm(film model.Film) (int64, error) {
 	    RETURNING film_id
 	`
 
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	var lastID int64
-	err := dbRaw.DB.QueryRow(query,
+	err = tx.QueryRow(query,
 		film.Title,
 		film.Description,
 		film.ReleaseYear,
@@ -92,10 +380,19 @@ func InsertFilm(film model.Film) (int64, error) {
 		return 0, err
 	}
 
+	film.ID = int(lastID)
+	if err := kafka.EnqueueFilmEvent(tx, kafka.NewFilmEvent(kafka.FilmCreated, &film, actor)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 	return lastID, nil
 }
 
-func UpdateFilm(filmId int, film model.Film) error {
+// UpdateFilm updates a film and enqueues its film.updated event in the same transaction
+func UpdateFilm(filmId int, film model.Film, actor string) error {
 	query := `
 		UPDATE film SET 
 			title = $1, description = $2, release_year = $3,
@@ -104,7 +401,13 @@ func UpdateFilm(filmId int, film model.Film) error {
 		WHERE film_id = $11
 	`
 
-	result, err := dbRaw.DB.Exec(query,
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(query,
 		film.Title,
 		film.Description,
 		film.ReleaseYear,
@@ -131,25 +434,33 @@ func UpdateFilm(filmId int, film model.Film) error {
 		return sql.ErrNoRows
 	}
 
-	return nil
+	film.ID = filmId
+	if err := kafka.EnqueueFilmEvent(tx, kafka.NewFilmEvent(kafka.FilmUpdated, &film, actor)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-func DeleteFilm(filmId int) error {
-	query := `DELETE FROM film WHERE film_id = $1`
+// DeleteFilm removes the film and enqueues its film.deleted event, carrying the deleted
+// record, in the same transaction
+func DeleteFilm(filmId int, actor string) error {
+	queryStr := fmt.Sprintf(`DELETE FROM film WHERE film_id = $1 RETURNING %s`, columnQuery)
 
-	result, err := dbRaw.DB.Exec(query, filmId)
+	tx, err := dbRaw.DB.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	rowsAffected, err := result.RowsAffected()
+	film, err := scanFilmRow(tx.QueryRow(queryStr, filmId))
 	if err != nil {
 		return err
 	}
 
-	if rowsAffected == 0 {
-		return sql.ErrNoRows
+	if err := kafka.EnqueueFilmEvent(tx, kafka.NewFilmEvent(kafka.FilmDeleted, film, actor)); err != nil {
+		return err
 	}
 
-	return nil
+	return tx.Commit()
 }
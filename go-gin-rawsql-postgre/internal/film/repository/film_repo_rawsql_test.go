@@ -4,6 +4,7 @@ import (
 	"film-rental/internal/film/model"
 	"film-rental/internal/film/repository"
 	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/pagination"
 	"testing"
 	"time"
 
@@ -35,6 +36,7 @@ func TestInsertFilm_Mock(t *testing.T) {
 		LastUpdate:      time.Now(),
 		LanguageId:      1,
 	}
+	mock.ExpectBegin()
 	mock.ExpectQuery(`INSERT INTO film .* RETURNING film_id`).
 		WithArgs(
 			film.Title,
@@ -49,8 +51,12 @@ func TestInsertFilm_Mock(t *testing.T) {
 			film.LanguageId,
 		).
 		WillReturnRows(sqlmock.NewRows([]string{"film_id"}).AddRow(expectedID))
+	mock.ExpectExec(`INSERT INTO outbox_messages`).
+		WithArgs("film-events", []byte("42"), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
 
-	id, err := repository.InsertFilm(film)
+	id, err := repository.InsertFilm(film, "mike")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -62,3 +68,135 @@ func TestInsertFilm_Mock(t *testing.T) {
 		t.Errorf("unmet expectations: %s", err)
 	}
 }
+
+// TestGetAllFilms_SearchAndFilter_Mock tests full-text search combined with facet filters
+func TestGetAllFilms_SearchAndFilter_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	filter := model.FilmFilter{
+		Search:          "epic drama",
+		Ratings:         []string{"PG", "PG-13"},
+		ReleaseYearFrom: 2000,
+		LengthMax:       120,
+		RentalRateMin:   1.5,
+		Page:            2,
+		Limit:           10,
+	}
+
+	rows := sqlmock.NewRows([]string{"film_id", "title", "description", "release_year", "rental_duration", "rental_rate", "length", "replacement_cost", "rating", "last_update", "language_id", "ts_rank"}).
+		AddRow(1, "Epic Film", "An epic drama", 2006, 3, 2.99, 100, 19.99, "PG", time.Now(), 1, 0.6)
+
+	mock.ExpectQuery(`SELECT (.+), ts_rank\(fulltext, websearch_to_tsquery\('english', \$1\)\) FROM film WHERE fulltext @@ websearch_to_tsquery\('english', \$1\) AND rating::text = ANY\(\$2\) AND release_year >= \$3 AND length <= \$4 AND rental_rate >= \$5 ORDER BY ts_rank\(fulltext, websearch_to_tsquery\('english', \$1\)\) DESC, film_id DESC LIMIT \$6 OFFSET \$7`).
+		WithArgs("epic drama", sqlmock.AnyArg(), 2000, 120, 1.5, 10, 10).
+		WillReturnRows(rows)
+	mock.ExpectQuery(`SELECT COUNT \(\*\) FROM film WHERE fulltext @@ (.+) AND rental_rate >= \$5$`).
+		WithArgs("epic drama", sqlmock.AnyArg(), 2000, 120, 1.5).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+
+	films, count, err := repository.GetAllFilms(filter)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if count != 11 || len(films) != 1 {
+		t.Fatalf("expected 1 film of 11, got %d of %d", len(films), count)
+	}
+	if films[0].Rank == nil || *films[0].Rank != 0.6 {
+		t.Fatalf("expected the search rank to be returned, got %v", films[0].Rank)
+	}
+
+	// Explicit sort overrides relevance ordering
+	filter = model.FilmFilter{SortBy: "rental_rate", SortDesc: true, Page: 1, Limit: 25}
+	mock.ExpectQuery(`SELECT (.+) FROM film ORDER BY rental_rate DESC, film_id DESC LIMIT \$1 OFFSET \$2`).
+		WithArgs(25, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"film_id"}))
+	mock.ExpectQuery(`SELECT COUNT \(\*\) FROM film$`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	if _, _, err := repository.GetAllFilms(filter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestGetFilmsAfter_Mock tests that a cursor continues the keyset in its own sort order
+func TestGetFilmsAfter_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	cursor := &pagination.Cursor{SortBy: "title", Value: "ACADEMY DINOSAUR", ID: 1}
+	filter := model.FilmFilter{LanguageId: 1, Limit: 1}
+
+	rows := sqlmock.NewRows([]string{"film_id", "title", "description", "release_year", "rental_duration", "rental_rate", "length", "replacement_cost", "rating", "last_update", "language_id"}).
+		AddRow(2, "ACE GOLDFINGER", "Desc", 2006, 3, 4.99, 48, 12.99, "G", time.Now(), 1).
+		AddRow(3, "ADAPTATION HOLES", "Desc", 2006, 7, 2.99, 50, 18.99, "NC-17", time.Now(), 1)
+
+	mock.ExpectQuery(`SELECT (.+) FROM film WHERE language_id = \$1 AND \(title, film_id\) > \(\$2, \$3\) ORDER BY title ASC, film_id ASC LIMIT \$4`).
+		WithArgs(1, "ACADEMY DINOSAUR", 1, 2).
+		WillReturnRows(rows)
+
+	films, next, count, err := repository.GetFilmsAfter(filter, cursor, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(films) != 1 || films[0].ID != 2 || count != nil {
+		t.Fatalf("unexpected page: %d films, count %v", len(films), count)
+	}
+
+	nextCursor, err := pagination.DecodeCursor(next)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nextCursor.SortBy != "title" || nextCursor.Value != "ACE GOLDFINGER" || nextCursor.ID != 2 {
+		t.Fatalf("unexpected next cursor: %+v", nextCursor)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestGetFilmsAfter_InvalidCursorValue_Mock tests that a cursor value of the wrong type
+// for its sort column is rejected before querying
+func TestGetFilmsAfter_InvalidCursorValue_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	// Values as they come out of a decoded cursor token
+	cursors := []*pagination.Cursor{
+		{SortBy: "title", Value: 42.0, ID: 1},
+		{SortBy: "release_year", Value: "2006", ID: 1},
+		{SortBy: "length", Value: 1.5, ID: 1},
+		{SortBy: "rental_rate", Value: nil, ID: 1},
+		{SortBy: "rating", Value: "X", ID: 1},
+		{SortBy: "last_update", Value: "yesterday", ID: 1},
+	}
+	for _, cursor := range cursors {
+		_, _, _, err := repository.GetFilmsAfter(model.FilmFilter{Limit: 10}, cursor, false)
+		if err != pagination.ErrInvalidCursor {
+			t.Errorf("expected ErrInvalidCursor for %+v, got %v", cursor, err)
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
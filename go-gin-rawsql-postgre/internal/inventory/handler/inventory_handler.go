@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/inventory/model"
+	"film-rental/internal/inventory/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/response"
+	"film-rental/pkg/scope"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxCopiesPerRequest = 100
+
+func GetInventory(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+	filmId, _ := strconv.Atoi(c.Query("film_id"))
+	storeId, _ := strconv.Atoi(c.Query("store_id"))
+
+	inventory, count, err := repository.GetAllInventory(filmId, storeId, page, limit, middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get inventory", err)
+		return
+	}
+
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), inventory)
+}
+
+func GetInventoryDetail(c *gin.Context) {
+	inventoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid inventory ID", err)
+		return
+	}
+
+	inv, err := repository.GetInventoryDetail(inventoryId, middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get inventory detail", err)
+		return
+	}
+	if inv == nil {
+		response.WriteError(c, http.StatusNotFound, "Inventory not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", inv)
+}
+
+func AddCopies(c *gin.Context) {
+	var req model.AddCopiesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if req.Copies == 0 {
+		req.Copies = 1
+	}
+	if req.Copies < 0 || req.Copies > maxCopiesPerRequest {
+		response.WriteError(c, http.StatusBadRequest, "Copies must be between 1 and 100", nil)
+		return
+	}
+
+	ids, err := repository.AddCopies(req.FilmId, req.StoreId, req.Copies, middleware.StoreScope(c))
+	if err != nil {
+		if err == scope.ErrOutOfScope {
+			response.WriteError(c, http.StatusForbidden, "Cannot add copies to another store", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to add copies", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"ids": ids})
+}
+
+func RetireCopy(c *gin.Context) {
+	inventoryId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid inventory ID", err)
+		return
+	}
+
+	// The reason is optional, so an empty body is accepted
+	var req model.RetireCopyRequest
+	_ = c.ShouldBindJSON(&req)
+
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Authorization payload not found", nil)
+		return
+	}
+
+	err = repository.RetireCopy(inventoryId, payload.Username, req.Reason, middleware.StoreScope(c))
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			response.WriteError(c, http.StatusNotFound, "Inventory not found", err)
+		case errors.Is(err, repository.ErrInventoryRented):
+			response.WriteError(c, http.StatusConflict, "Inventory is rented out", err)
+		case errors.Is(err, repository.ErrInventoryRetired):
+			response.WriteError(c, http.StatusConflict, "Inventory is already retired", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to retire copy", err)
+		}
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Copy retired successfully", nil)
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+// Inventory status values
+const (
+	StatusAvailable = "available"
+	StatusRented    = "rented"
+	StatusRetired   = "retired"
+)
+
+type Inventory struct {
+	InventoryId int       `json:"inventory_id"`
+	FilmId      int       `json:"film_id"`
+	StoreId     int       `json:"store_id"`
+	LastUpdate  time.Time `json:"last_update"`
+	Status      string    `json:"status"`
+}
+
+// InventoryRetirement records a copy taken out of circulation. Retired copies are
+// kept in the inventory table so their rental history stays intact.
+type InventoryRetirement struct {
+	InventoryId int       `gorm:"primaryKey;autoIncrement:false" json:"inventory_id"`
+	RetiredBy   string    `gorm:"size:100;not null" json:"retired_by"`
+	Reason      string    `gorm:"type:text" json:"reason"`
+	RetiredAt   time.Time `gorm:"not null" json:"retired_at"`
+}
+
+// StoreAvailability summarises the copies of a film held by one store
+type StoreAvailability struct {
+	StoreId     int `json:"store_id"`
+	TotalCopies int `json:"total_copies"`
+	InStock     int `json:"in_stock"`
+	RentedOut   int `json:"rented_out"`
+}
+
+// AddCopiesRequest is used for adding copies of a film to a store
+type AddCopiesRequest struct {
+	FilmId  int `json:"film_id" binding:"required"`
+	StoreId int `json:"store_id" binding:"required"`
+	Copies  int `json:"copies"`
+}
+
+// RetireCopyRequest is used for retiring a copy
+type RetireCopyRequest struct {
+	Reason string `json:"reason"`
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/inventory/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/scope"
+	"time"
+)
+
+var (
+	ErrInventoryRented  = errors.New("inventory is rented out")
+	ErrInventoryRetired = errors.New("inventory is already retired")
+)
+
+const queryColumns = `i.inventory_id, i.film_id, i.store_id, i.last_update,
+	CASE
+		WHEN ir.inventory_id IS NOT NULL THEN 'retired'
+		WHEN EXISTS (SELECT 1 FROM rental r WHERE r.inventory_id = i.inventory_id AND r.return_date IS NULL) THEN 'rented'
+		ELSE 'available'
+	END AS status`
+
+const queryFrom = ` FROM inventory i LEFT JOIN inventory_retirements ir ON ir.inventory_id = i.inventory_id`
+
+func scanInventoryRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Inventory, error) {
+	var inv model.Inventory
+	err := scanner.Scan(&inv.InventoryId, &inv.FilmId, &inv.StoreId, &inv.LastUpdate, &inv.Status)
+	return &inv, err
+}
+
+// GetAllInventory returns a page of copies visible within storeScope. A zero filmId or
+// storeId disables that filter.
+func GetAllInventory(filmId int, storeId int, page int, limit int, storeScope int) ([]*model.Inventory, int, error) {
+	where := ` WHERE ($1 = 0 OR i.film_id = $1) AND ($2 = 0 OR i.store_id = $2) AND ($3 = 0 OR i.store_id = $3)`
+	queryStr := `SELECT ` + queryColumns + queryFrom + where + ` ORDER BY i.inventory_id LIMIT $4 OFFSET $5`
+
+	rows, err := dbRaw.DB.Query(queryStr, filmId, storeId, storeScope, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow(`SELECT COUNT (*)`+queryFrom+where, filmId, storeId, storeScope).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	var inventory []*model.Inventory
+	for rows.Next() {
+		if inv, err := scanInventoryRow(rows); err != nil {
+			continue
+		} else {
+			inventory = append(inventory, inv)
+		}
+	}
+
+	return inventory, totalCount, nil
+}
+
+// GetInventoryDetail returns a copy, or nil when it does not exist within storeScope
+func GetInventoryDetail(inventoryId int, storeScope int) (*model.Inventory, error) {
+	queryStr := `SELECT ` + queryColumns + queryFrom + ` WHERE i.inventory_id = $1 AND ($2 = 0 OR i.store_id = $2)`
+
+	inv, err := scanInventoryRow(dbRaw.DB.QueryRow(queryStr, inventoryId, storeScope))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// GetFilmAvailability returns, per store, how many circulating copies of a film
+// are in stock and how many are rented out. Retired copies are not counted.
+func GetFilmAvailability(filmId int) ([]model.StoreAvailability, error) {
+	query := `
+		SELECT i.store_id,
+			COUNT(*) AS total_copies,
+			COUNT(r.rental_id) AS rented_out
+		FROM inventory i
+		LEFT JOIN rental r ON r.inventory_id = i.inventory_id AND r.return_date IS NULL
+		WHERE i.film_id = $1
+			AND NOT EXISTS (SELECT 1 FROM inventory_retirements ir WHERE ir.inventory_id = i.inventory_id)
+		GROUP BY i.store_id
+		ORDER BY i.store_id
+	`
+
+	rows, err := dbRaw.DB.Query(query, filmId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	availability := []model.StoreAvailability{}
+	for rows.Next() {
+		var a model.StoreAvailability
+		if err := rows.Scan(&a.StoreId, &a.TotalCopies, &a.RentedOut); err != nil {
+			return nil, err
+		}
+		a.InStock = a.TotalCopies - a.RentedOut
+		availability = append(availability, a)
+	}
+
+	return availability, rows.Err()
+}
+
+// AddCopies inserts the given number of copies of a film for a store. It returns
+// scope.ErrOutOfScope when the store is outside storeScope.
+func AddCopies(filmId int, storeId int, copies int, storeScope int) ([]int64, error) {
+	if !scope.Allows(storeScope, storeId) {
+		return nil, scope.ErrOutOfScope
+	}
+
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO inventory (film_id, store_id, last_update) VALUES ($1, $2, $3) RETURNING inventory_id`
+
+	now := time.Now()
+	ids := make([]int64, 0, copies)
+	for i := 0; i < copies; i++ {
+		var id int64
+		if err := tx.QueryRow(query, filmId, storeId, now).Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return ids, nil
+}
+
+// RetireCopy takes a copy out of circulation. It returns sql.ErrNoRows when the copy
+// does not exist within storeScope, ErrInventoryRented when it is rented out and
+// ErrInventoryRetired when it was already retired.
+func RetireCopy(inventoryId int, retiredBy string, reason string, storeScope int) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`SELECT inventory_id FROM inventory WHERE inventory_id = $1 AND ($2 = 0 OR store_id = $2) FOR UPDATE`, inventoryId, storeScope).Scan(&id)
+	if err != nil {
+		return err
+	}
+
+	var rentedOut bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM rental WHERE inventory_id = $1 AND return_date IS NULL)`, inventoryId).Scan(&rentedOut); err != nil {
+		return err
+	}
+	if rentedOut {
+		return ErrInventoryRented
+	}
+
+	query := `
+		INSERT INTO inventory_retirements (inventory_id, retired_by, reason, retired_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (inventory_id) DO NOTHING
+	`
+	result, err := tx.Exec(query, inventoryId, retiredBy, reason, time.Now())
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrInventoryRetired
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,70 @@
+package repository_test
+
+import (
+	"film-rental/internal/inventory/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestGetFilmAvailability_Mock tests that in-stock copies are derived from total and rented copies
+func TestGetFilmAvailability_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	// Set the mock database to the global db.DB
+	dbRaw.DB = mockDB
+
+	mock.ExpectQuery(`SELECT i.store_id, (.+) FROM inventory i (.+) GROUP BY i.store_id`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"store_id", "total_copies", "rented_out"}).
+			AddRow(1, 4, 1).
+			AddRow(2, 3, 3))
+
+	availability, err := repository.GetFilmAvailability(1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(availability) != 2 {
+		t.Fatalf("expected 2 stores, got %d", len(availability))
+	}
+	if availability[0].InStock != 3 || availability[1].InStock != 0 {
+		t.Fatalf("unexpected in-stock counts: %+v", availability)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestRetireCopy_Mock tests that a rented-out copy cannot be retired
+func TestRetireCopy_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT inventory_id FROM inventory WHERE inventory_id = \$1 AND \(\$2 = 0 OR store_id = \$2\) FOR UPDATE`).
+		WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"inventory_id"}).AddRow(10))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM rental`).
+		WithArgs(10).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	if err := repository.RetireCopy(10, "admin", "damaged", 0); err != repository.ErrInventoryRented {
+		t.Fatalf("expected ErrInventoryRented, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
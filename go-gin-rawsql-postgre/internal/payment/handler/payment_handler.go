@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/payment/model"
+	"film-rental/internal/payment/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const reportDateLayout = "2006-01-02"
+
+func GetPayments(c *gin.Context) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+	rentalId, _ := strconv.Atoi(c.Query("rental_id"))
+
+	payments, count, err := repository.GetAllPayments(rentalId, page, limit)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get payments", err)
+		return
+	}
+
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), payments)
+}
+
+func GetRentalBalance(c *gin.Context) {
+	rentalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid rental ID", err)
+		return
+	}
+
+	balance, err := repository.GetRentalBalance(rentalId, time.Now())
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rental balance", err)
+		return
+	}
+	if balance == nil {
+		response.WriteError(c, http.StatusNotFound, "Rental not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", balance)
+}
+
+// AddPayment records a full or partial payment against a rental
+func AddPayment(c *gin.Context) {
+	recordPayment(c, 1)
+}
+
+// RefundPayment refunds part or all of what was paid for a rental
+func RefundPayment(c *gin.Context) {
+	recordPayment(c, -1)
+}
+
+func recordPayment(c *gin.Context, sign float64) {
+	var req model.PaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.Amount <= 0 {
+		response.WriteError(c, http.StatusBadRequest, "Amount must be greater than 0", nil)
+		return
+	}
+
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Authorization payload not found", nil)
+		return
+	}
+
+	id, err := repository.RecordPayment(req.RentalId, sign*req.Amount, payload.Username, time.Now())
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			response.WriteError(c, http.StatusNotFound, "Rental not found", err)
+		case errors.Is(err, repository.ErrOverpayment), errors.Is(err, repository.ErrRefundExceedsPaid):
+			response.WriteError(c, http.StatusUnprocessableEntity, err.Error(), err)
+		case errors.Is(err, repository.ErrStaffNotFound):
+			response.WriteError(c, http.StatusUnauthorized, "Staff not found", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to record payment", err)
+		}
+		return
+	}
+
+	balance, err := repository.GetRentalBalance(req.RentalId, time.Now())
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rental balance", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id, "balance": balance})
+}
+
+func ReportRentalLost(c *gin.Context) {
+	rentalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid rental ID", err)
+		return
+	}
+
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Authorization payload not found", nil)
+		return
+	}
+
+	err = repository.ReportRentalLost(rentalId, payload.Username, time.Now())
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			response.WriteError(c, http.StatusNotFound, "Rental not found", err)
+		case errors.Is(err, repository.ErrRentalLost), errors.Is(err, repository.ErrRentalReturned):
+			response.WriteError(c, http.StatusConflict, err.Error(), err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to report rental lost", err)
+		}
+		return
+	}
+
+	balance, err := repository.GetRentalBalance(rentalId, time.Now())
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rental balance", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Rental reported lost", balance)
+}
+
+// GetRevenueReport returns revenue grouped by day, store, staff or category.
+// The from and to query parameters are dates (YYYY-MM-DD) and default to the last 30 days.
+func GetRevenueReport(c *gin.Context) {
+	groupBy := c.DefaultQuery("group_by", model.GroupByDay)
+	if !repository.IsValidGroupBy(groupBy) {
+		response.WriteError(c, http.StatusBadRequest, "Invalid group_by. Must be 'day', 'store', 'staff' or 'category'", nil)
+		return
+	}
+
+	to := time.Now().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	from := to.AddDate(0, 0, -30)
+	if v := c.Query("from"); v != "" {
+		parsed, err := time.Parse(reportDateLayout, v)
+		if err != nil {
+			response.WriteError(c, http.StatusBadRequest, "Invalid from date", err)
+			return
+		}
+		from = parsed
+	}
+	if v := c.Query("to"); v != "" {
+		parsed, err := time.Parse(reportDateLayout, v)
+		if err != nil {
+			response.WriteError(c, http.StatusBadRequest, "Invalid to date", err)
+			return
+		}
+		// The to date is inclusive for callers
+		to = parsed.AddDate(0, 0, 1)
+	}
+	if !from.Before(to) {
+		response.WriteError(c, http.StatusBadRequest, "from must not be after to", nil)
+		return
+	}
+
+	report, err := repository.GetRevenueReport(groupBy, from, to)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get revenue report", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Success", report)
+}
@@ -0,0 +1,55 @@
+package model
+
+import "time"
+
+// Revenue report groupings
+const (
+	GroupByDay      = "day"
+	GroupByStore    = "store"
+	GroupByStaff    = "staff"
+	GroupByCategory = "category"
+)
+
+type Payment struct {
+	PaymentId   int       `json:"payment_id"`
+	CustomerId  int       `json:"customer_id"`
+	StaffId     int       `json:"staff_id"`
+	RentalId    int       `json:"rental_id"`
+	Amount      float64   `json:"amount"`
+	PaymentDate time.Time `json:"payment_date"`
+}
+
+// RentalLoss records that a rented copy was reported lost, which adds the film's
+// replacement cost to the amount due for the rental.
+type RentalLoss struct {
+	RentalId   int       `gorm:"primaryKey;autoIncrement:false" json:"rental_id"`
+	ReportedBy string    `gorm:"size:100;not null" json:"reported_by"`
+	ReportedAt time.Time `gorm:"not null" json:"reported_at"`
+}
+
+// RentalBalance is the breakdown of what is owed for a rental
+type RentalBalance struct {
+	RentalId          int     `json:"rental_id"`
+	CustomerId        int     `json:"customer_id"`
+	RentalCharge      float64 `json:"rental_charge"`
+	LateFee           float64 `json:"late_fee"`
+	ReplacementCharge float64 `json:"replacement_charge"`
+	AmountDue         float64 `json:"amount_due"`
+	AmountPaid        float64 `json:"amount_paid"`
+	Balance           float64 `json:"balance"`
+	Lost              bool    `json:"lost"`
+}
+
+// RevenueRow is one group of a revenue report
+type RevenueRow struct {
+	Group        string  `json:"group"`
+	PaymentCount int     `json:"payment_count"`
+	Revenue      float64 `json:"revenue"`
+	Refunded     float64 `json:"refunded"`
+}
+
+// PaymentRequest is used for recording payments and refunds against a rental
+type PaymentRequest struct {
+	RentalId int     `json:"rental_id" binding:"required"`
+	Amount   float64 `json:"amount" binding:"required"`
+}
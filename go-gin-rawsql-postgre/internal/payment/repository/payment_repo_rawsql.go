@@ -0,0 +1,288 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/payment/model"
+	rentalModel "film-rental/internal/rental/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"fmt"
+	"math"
+	"time"
+)
+
+var (
+	ErrOverpayment       = errors.New("amount exceeds the outstanding balance")
+	ErrRefundExceedsPaid = errors.New("refund exceeds the amount paid")
+	ErrStaffNotFound     = errors.New("staff not found")
+	ErrRentalLost        = errors.New("rental has already been reported lost")
+	ErrRentalReturned    = errors.New("rental has already been returned")
+)
+
+const queryColumns = "payment_id, customer_id, staff_id, rental_id, amount, payment_date"
+
+// revenueGroupExpressions maps a report grouping to the SQL expression used as the group key
+var revenueGroupExpressions = map[string]string{
+	model.GroupByDay:      "to_char(date_trunc('day', p.payment_date), 'YYYY-MM-DD')",
+	model.GroupByStore:    "s.store_id::text",
+	model.GroupByStaff:    "s.username",
+	model.GroupByCategory: "COALESCE(c.name, 'Uncategorized')",
+}
+
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func scanPaymentRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Payment, error) {
+	var p model.Payment
+	err := scanner.Scan(&p.PaymentId, &p.CustomerId, &p.StaffId, &p.RentalId, &p.Amount, &p.PaymentDate)
+	return &p, err
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// IsValidGroupBy checks if a revenue report grouping is supported
+func IsValidGroupBy(groupBy string) bool {
+	_, exists := revenueGroupExpressions[groupBy]
+	return exists
+}
+
+// GetAllPayments returns a page of payments. A zero rentalId disables the rental filter.
+func GetAllPayments(rentalId int, page int, limit int) ([]*model.Payment, int, error) {
+	where := ` WHERE ($1 = 0 OR rental_id = $1)`
+	queryStr := `SELECT ` + queryColumns + ` FROM payment` + where + ` ORDER BY payment_date DESC, payment_id DESC LIMIT $2 OFFSET $3`
+
+	rows, err := dbRaw.DB.Query(queryStr, rentalId, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM payment"+where, rentalId).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	var payments []*model.Payment
+	for rows.Next() {
+		if p, err := scanPaymentRow(rows); err != nil {
+			continue
+		} else {
+			payments = append(payments, p)
+		}
+	}
+
+	return payments, totalCount, nil
+}
+
+// getRentalBalance computes what is owed for a rental. The rental rate is always due,
+// late fees accrue per day past the due date until the copy is returned or reported
+// lost, and a lost copy adds the film's replacement cost. When lock is set the rental
+// row is locked so concurrent payments are serialised.
+func getRentalBalance(q queryRower, rentalId int, now time.Time, lock bool) (*model.RentalBalance, error) {
+	query := `
+		SELECT r.rental_id, r.customer_id,
+			r.rental_date + f.rental_duration * INTERVAL '1 day' AS due_date, r.return_date,
+			f.rental_rate, f.replacement_cost, rl.reported_at,
+			COALESCE((SELECT SUM(p.amount) FROM payment p WHERE p.rental_id = r.rental_id), 0) AS amount_paid
+		FROM rental r
+		JOIN inventory i ON i.inventory_id = r.inventory_id
+		JOIN film f ON f.film_id = i.film_id
+		LEFT JOIN rental_losses rl ON rl.rental_id = r.rental_id
+		WHERE r.rental_id = $1
+	`
+	if lock {
+		query += ` FOR UPDATE OF r`
+	}
+
+	var (
+		b               model.RentalBalance
+		dueDate         time.Time
+		returnDate      *time.Time
+		lostAt          *time.Time
+		rentalRate      float64
+		replacementCost float64
+	)
+	err := q.QueryRow(query, rentalId).Scan(
+		&b.RentalId, &b.CustomerId, &dueDate, &returnDate,
+		&rentalRate, &replacementCost, &lostAt, &b.AmountPaid,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rental := rentalModel.Rental{DueDate: dueDate, ReturnDate: returnDate, RentalRate: float32(rentalRate)}
+	if lostAt != nil {
+		b.Lost = true
+		b.ReplacementCharge = replacementCost
+		rental.ReturnDate = lostAt
+	}
+	rental.ApplyLateFee(now)
+
+	b.RentalCharge = rentalRate
+	b.LateFee = roundCents(float64(rental.DaysOverdue) * rentalRate)
+	b.AmountDue = roundCents(b.RentalCharge + b.LateFee + b.ReplacementCharge)
+	b.AmountPaid = roundCents(b.AmountPaid)
+	b.Balance = roundCents(b.AmountDue - b.AmountPaid)
+
+	return &b, nil
+}
+
+// GetRentalBalance returns the amount due, paid and outstanding for a rental
+func GetRentalBalance(rentalId int, now time.Time) (*model.RentalBalance, error) {
+	b, err := getRentalBalance(dbRaw.DB, rentalId, now, false)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return b, err
+}
+
+// RecordPayment records a payment against a rental. A positive amount is a payment and
+// may be partial but cannot exceed the outstanding balance; a negative amount is a
+// refund and cannot exceed what has been paid so far.
+func RecordPayment(rentalId int, amount float64, staffUsername string, paymentDate time.Time) (int64, error) {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	balance, err := getRentalBalance(tx, rentalId, paymentDate, true)
+	if err != nil {
+		return 0, err
+	}
+
+	amount = roundCents(amount)
+	if amount > 0 && amount > balance.Balance {
+		return 0, ErrOverpayment
+	}
+	if amount < 0 && -amount > balance.AmountPaid {
+		return 0, ErrRefundExceedsPaid
+	}
+
+	query := `
+		INSERT INTO payment (customer_id, staff_id, rental_id, amount, payment_date)
+		SELECT $1, staff_id, $2, $3, $4 FROM staff WHERE username = $5
+		RETURNING payment_id
+	`
+
+	var lastID int64
+	err = tx.QueryRow(query, balance.CustomerId, rentalId, amount, paymentDate, staffUsername).Scan(&lastID)
+	if err == sql.ErrNoRows {
+		return 0, ErrStaffNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return lastID, nil
+}
+
+// ReportRentalLost marks an outstanding rental as lost and retires its copy so it no
+// longer counts as stock. It returns sql.ErrNoRows when the rental does not exist.
+func ReportRentalLost(rentalId int, reportedBy string, reportedAt time.Time) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var inventoryId int
+	var returnDate *time.Time
+	err = tx.QueryRow(`SELECT inventory_id, return_date FROM rental WHERE rental_id = $1 FOR UPDATE`, rentalId).Scan(&inventoryId, &returnDate)
+	if err != nil {
+		return err
+	}
+	if returnDate != nil {
+		return ErrRentalReturned
+	}
+
+	result, err := tx.Exec(`
+		INSERT INTO rental_losses (rental_id, reported_by, reported_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (rental_id) DO NOTHING`, rentalId, reportedBy, reportedAt)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRentalLost
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO inventory_retirements (inventory_id, retired_by, reason, retired_at)
+		VALUES ($1, $2, 'lost', $3)
+		ON CONFLICT (inventory_id) DO NOTHING`, inventoryId, reportedBy, reportedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRevenueReport sums payments between from (inclusive) and to (exclusive) grouped
+// by day, store, staff member or film category. Refunds are reported separately and
+// deducted from revenue. A film can be in several categories, so by category a payment
+// counts towards the film's lowest category_id only; the group totals add up to the
+// real revenue.
+func GetRevenueReport(groupBy string, from time.Time, to time.Time) ([]model.RevenueRow, error) {
+	groupExpr, exists := revenueGroupExpressions[groupBy]
+	if !exists {
+		return nil, fmt.Errorf("unsupported group by %q", groupBy)
+	}
+
+	joins := ` JOIN staff s ON s.staff_id = p.staff_id`
+	if groupBy == model.GroupByCategory {
+		joins += `
+			JOIN rental r ON r.rental_id = p.rental_id
+			JOIN inventory i ON i.inventory_id = r.inventory_id
+			LEFT JOIN LATERAL (
+				SELECT category_id FROM film_category
+				WHERE film_id = i.film_id
+				ORDER BY category_id
+				LIMIT 1
+			) fc ON true
+			LEFT JOIN category c ON c.category_id = fc.category_id`
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s AS grp,
+			COUNT(*) FILTER (WHERE p.amount > 0) AS payment_count,
+			COALESCE(SUM(p.amount), 0) AS revenue,
+			COALESCE(-SUM(p.amount) FILTER (WHERE p.amount < 0), 0) AS refunded
+		FROM payment p%s
+		WHERE p.payment_date >= $1 AND p.payment_date < $2
+		GROUP BY grp
+		ORDER BY grp
+	`, groupExpr, joins)
+
+	rows, err := dbRaw.DB.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	report := []model.RevenueRow{}
+	for rows.Next() {
+		var row model.RevenueRow
+		if err := rows.Scan(&row.Group, &row.PaymentCount, &row.Revenue, &row.Refunded); err != nil {
+			return nil, err
+		}
+		row.Revenue = roundCents(row.Revenue)
+		row.Refunded = roundCents(row.Refunded)
+		report = append(report, row)
+	}
+
+	return report, rows.Err()
+}
@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"film-rental/internal/payment/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var balanceColumns = []string{"rental_id", "customer_id", "due_date", "return_date", "rental_rate", "replacement_cost", "reported_at", "amount_paid"}
+
+// TestGetRentalBalance_Mock tests the amount due for overdue and lost rentals
+func TestGetRentalBalance_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	// Set the mock database to the global db.DB
+	dbRaw.DB = mockDB
+
+	now := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	due := now.Add(-48 * time.Hour)
+
+	// Test case 1: two days overdue with a partial payment
+	mock.ExpectQuery(`SELECT r.rental_id, (.+) FROM rental r`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(1, 7, due, nil, 2.99, 19.99, nil, 2.99))
+
+	balance, err := repository.GetRentalBalance(1, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if balance.LateFee != 5.98 || balance.AmountDue != 8.97 || balance.Balance != 5.98 {
+		t.Fatalf("unexpected balance: %+v", balance)
+	}
+
+	// Test case 2: reported lost on the due date adds the replacement cost only
+	mock.ExpectQuery(`SELECT r.rental_id, (.+) FROM rental r`).
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(2, 7, due, nil, 2.99, 19.99, due, 0))
+
+	balance, err = repository.GetRentalBalance(2, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !balance.Lost || balance.LateFee != 0 || balance.AmountDue != 22.98 {
+		t.Fatalf("unexpected balance: %+v", balance)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestRecordPayment_Overpayment_Mock tests that payments cannot exceed the outstanding balance
+func TestRecordPayment_Overpayment_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	now := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT r.rental_id, (.+) FOR UPDATE OF r`).
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(balanceColumns).AddRow(1, 7, now.Add(time.Hour), nil, 2.99, 19.99, nil, 0))
+	mock.ExpectRollback()
+
+	if _, err := repository.RecordPayment(1, 5, "staff1", now); err != repository.ErrOverpayment {
+		t.Fatalf("expected ErrOverpayment, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestGetRevenueReport_ByCategory_Mock tests that a film in two categories counts its
+// payments towards one category only
+func TestGetRevenueReport_ByCategory_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(0, 1, 0)
+
+	// Film 1 is in Action and Comedy; its 4.99 payment is reported under Action alone
+	mock.ExpectQuery(`LEFT JOIN LATERAL \(\s*SELECT category_id FROM film_category\s*WHERE film_id = i.film_id\s*ORDER BY category_id\s*LIMIT 1\s*\) fc ON true`).
+		WithArgs(from, to).
+		WillReturnRows(sqlmock.NewRows([]string{"grp", "payment_count", "revenue", "refunded"}).
+			AddRow("Action", 1, 4.99, 0))
+
+	report, err := repository.GetRevenueReport("category", from, to)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(report) != 1 || report[0].Group != "Action" || report[0].Revenue != 4.99 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/rental/model"
+	"film-rental/internal/rental/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/response"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func parsePagination(c *gin.Context) (int, int) {
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 1 {
+		limit = 25
+	}
+	return page, limit
+}
+
+func GetRentals(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	rentals, count, err := repository.GetAllRentals(page, limit, middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rentals", err)
+		return
+	}
+
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), rentals)
+}
+
+func GetOverdueRentals(c *gin.Context) {
+	page, limit := parsePagination(c)
+
+	rentals, count, err := repository.GetOverdueRentals(time.Now(), page, limit, middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get overdue rentals", err)
+		return
+	}
+
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), rentals)
+}
+
+func GetRentalDetail(c *gin.Context) {
+	rentalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid rental ID", err)
+		return
+	}
+
+	rental, err := repository.GetRentalDetail(rentalId, middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rental detail", err)
+		return
+	}
+	if rental == nil {
+		response.WriteError(c, http.StatusNotFound, "Rental not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", rental)
+}
+
+func CheckoutRental(c *gin.Context) {
+	var req model.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Authorization payload not found", nil)
+		return
+	}
+
+	id, err := repository.CheckoutRental(req, payload.Username, time.Now(), middleware.StoreScope(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrInventoryNotFound):
+			response.WriteError(c, http.StatusNotFound, "Inventory not found", err)
+		case errors.Is(err, repository.ErrCustomerNotFound):
+			response.WriteError(c, http.StatusNotFound, "Customer not found", err)
+		case errors.Is(err, repository.ErrCustomerInactive):
+			response.WriteError(c, http.StatusConflict, "Customer is inactive", err)
+		case errors.Is(err, repository.ErrInventoryRetired):
+			response.WriteError(c, http.StatusConflict, "Inventory has been retired", err)
+		case errors.Is(err, repository.ErrInventoryRented):
+			response.WriteError(c, http.StatusConflict, "Inventory is already rented out", err)
+		case errors.Is(err, repository.ErrStaffNotFound):
+			response.WriteError(c, http.StatusUnauthorized, "Staff not found", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to check out rental", err)
+		}
+		return
+	}
+
+	rental, err := repository.GetRentalDetail(int(id), middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rental detail", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusCreated, "Success", rental)
+}
+
+func ReturnRental(c *gin.Context) {
+	rentalId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid rental ID", err)
+		return
+	}
+
+	err = repository.ReturnRental(rentalId, time.Now(), middleware.StoreScope(c))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Rental not found", err)
+			return
+		}
+		if errors.Is(err, repository.ErrRentalReturned) {
+			response.WriteError(c, http.StatusConflict, "Rental has already been returned", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to return rental", err)
+		return
+	}
+
+	rental, err := repository.GetRentalDetail(rentalId, middleware.StoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get rental detail", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Rental returned successfully", rental)
+}
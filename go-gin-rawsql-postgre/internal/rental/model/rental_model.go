@@ -0,0 +1,47 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+type Rental struct {
+	RentalId    int        `json:"rental_id"`
+	RentalDate  time.Time  `json:"rental_date"`
+	InventoryId int        `json:"inventory_id"`
+	CustomerId  int        `json:"customer_id"`
+	ReturnDate  *time.Time `json:"return_date"`
+	StaffId     int        `json:"staff_id"`
+	LastUpdate  time.Time  `json:"last_update"`
+	DueDate     time.Time  `json:"due_date"`
+	FilmId      int        `json:"film_id"`
+	Title       string     `json:"title"`
+	RentalRate  float32    `json:"rental_rate"`
+	DaysOverdue int        `json:"days_overdue"`
+	LateFee     float32    `json:"late_fee"`
+}
+
+// CheckoutRequest is used for renting an inventory copy to a customer
+type CheckoutRequest struct {
+	InventoryId int `json:"inventory_id" binding:"required"`
+	CustomerId  int `json:"customer_id" binding:"required"`
+}
+
+// ApplyLateFee fills DaysOverdue and LateFee relative to the given time.
+// A rental is charged its rental rate for every started day past the due date,
+// counted up to the return date when the copy has already been returned.
+func (r *Rental) ApplyLateFee(now time.Time) {
+	end := now
+	if r.ReturnDate != nil {
+		end = *r.ReturnDate
+	}
+
+	r.DaysOverdue = 0
+	r.LateFee = 0
+	if !end.After(r.DueDate) {
+		return
+	}
+
+	r.DaysOverdue = int(math.Ceil(end.Sub(r.DueDate).Hours() / 24))
+	r.LateFee = float32(r.DaysOverdue) * r.RentalRate
+}
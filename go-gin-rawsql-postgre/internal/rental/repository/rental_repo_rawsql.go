@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/rental/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+)
+
+var (
+	ErrInventoryNotFound = errors.New("inventory not found")
+	ErrInventoryRented   = errors.New("inventory is already rented out")
+	ErrInventoryRetired  = errors.New("inventory has been retired")
+	ErrStaffNotFound     = errors.New("staff not found")
+	ErrRentalReturned    = errors.New("rental has already been returned")
+	ErrCustomerNotFound  = errors.New("customer not found")
+	ErrCustomerInactive  = errors.New("customer is inactive")
+)
+
+const queryColumns = `r.rental_id, r.rental_date, r.inventory_id, r.customer_id, r.return_date, r.staff_id, r.last_update,
+	r.rental_date + f.rental_duration * INTERVAL '1 day' AS due_date, f.film_id, f.title, f.rental_rate`
+
+const queryFrom = ` FROM rental r
+	JOIN inventory i ON i.inventory_id = r.inventory_id
+	JOIN film f ON f.film_id = i.film_id`
+
+func scanRentalRow(scanner interface {
+	Scan(dest ...any) error
+}) (*model.Rental, error) {
+	var r model.Rental
+	err := scanner.Scan(
+		&r.RentalId, &r.RentalDate, &r.InventoryId, &r.CustomerId,
+		&r.ReturnDate, &r.StaffId, &r.LastUpdate,
+		&r.DueDate, &r.FilmId, &r.Title, &r.RentalRate,
+	)
+	return &r, err
+}
+
+// storeFilter restricts rentals to copies of the store in storeScope ($1); scope.AllStores
+// (0) disables it
+const storeFilter = ` WHERE ($1 = 0 OR i.store_id = $1)`
+
+// GetAllRentals lists rentals of copies visible within storeScope
+func GetAllRentals(page int, limit int, storeScope int) ([]*model.Rental, int, error) {
+	queryStr := `SELECT ` + queryColumns + queryFrom + storeFilter + ` ORDER BY r.rental_id DESC LIMIT $2 OFFSET $3`
+
+	rows, err := dbRaw.DB.Query(queryStr, storeScope, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow(`SELECT COUNT (*)`+queryFrom+storeFilter, storeScope).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	var rentals []*model.Rental
+	for rows.Next() {
+		if r, err := scanRentalRow(rows); err != nil {
+			continue
+		} else {
+			r.ApplyLateFee(now)
+			rentals = append(rentals, r)
+		}
+	}
+
+	return rentals, totalCount, nil
+}
+
+// GetRentalDetail returns a rental, or nil when it does not exist within storeScope
+func GetRentalDetail(rentalId int, storeScope int) (*model.Rental, error) {
+	queryStr := `SELECT ` + queryColumns + queryFrom + storeFilter + ` AND r.rental_id = $2`
+
+	r, err := scanRentalRow(dbRaw.DB.QueryRow(queryStr, storeScope, rentalId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	r.ApplyLateFee(time.Now())
+	return r, nil
+}
+
+// GetOverdueRentals lists rentals within storeScope that are still out past their due date
+func GetOverdueRentals(now time.Time, page int, limit int, storeScope int) ([]*model.Rental, int, error) {
+	overdueFilter := storeFilter + ` AND r.return_date IS NULL AND r.rental_date + f.rental_duration * INTERVAL '1 day' < $2`
+	queryStr := `SELECT ` + queryColumns + queryFrom + overdueFilter + ` ORDER BY due_date LIMIT $3 OFFSET $4`
+
+	rows, err := dbRaw.DB.Query(queryStr, storeScope, now, limit, (page-1)*limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow(`SELECT COUNT (*)`+queryFrom+overdueFilter, storeScope, now).Scan(&totalCount); err != nil {
+		return nil, 0, err
+	}
+
+	var rentals []*model.Rental
+	for rows.Next() {
+		if r, err := scanRentalRow(rows); err != nil {
+			continue
+		} else {
+			r.ApplyLateFee(now)
+			rentals = append(rentals, r)
+		}
+	}
+
+	return rentals, totalCount, nil
+}
+
+// CheckoutRental rents an inventory copy to a customer on behalf of the staff member
+// identified by staffUsername. The availability check and the insert run in one
+// transaction so the same copy cannot be checked out twice. Copies outside
+// storeScope are reported as ErrInventoryNotFound, and customers that were
+// deactivated as ErrCustomerInactive.
+func CheckoutRental(req model.CheckoutRequest, staffUsername string, rentalDate time.Time, storeScope int) (int64, error) {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var retired bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM inventory_retirements ir WHERE ir.inventory_id = i.inventory_id)
+		FROM inventory i WHERE i.inventory_id = $1 AND ($2 = 0 OR i.store_id = $2) FOR UPDATE`, req.InventoryId, storeScope).Scan(&retired)
+	if err == sql.ErrNoRows {
+		return 0, ErrInventoryNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if retired {
+		return 0, ErrInventoryRetired
+	}
+
+	var customerActive bool
+	err = tx.QueryRow(`SELECT activebool FROM customer WHERE customer_id = $1`, req.CustomerId).Scan(&customerActive)
+	if err == sql.ErrNoRows {
+		return 0, ErrCustomerNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !customerActive {
+		return 0, ErrCustomerInactive
+	}
+
+	var rentedOut bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM rental WHERE inventory_id = $1 AND return_date IS NULL)`, req.InventoryId).Scan(&rentedOut); err != nil {
+		return 0, err
+	}
+	if rentedOut {
+		return 0, ErrInventoryRented
+	}
+
+	query := `
+		INSERT INTO rental (rental_date, inventory_id, customer_id, staff_id, last_update)
+		SELECT $1, $2, $3, staff_id, $1 FROM staff WHERE username = $4
+		RETURNING rental_id
+	`
+
+	var lastID int64
+	err = tx.QueryRow(query, rentalDate, req.InventoryId, req.CustomerId, staffUsername).Scan(&lastID)
+	if err == sql.ErrNoRows {
+		return 0, ErrStaffNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return lastID, nil
+}
+
+// ReturnRental records the return of a rental. It returns sql.ErrNoRows when the
+// rental does not exist within storeScope and ErrRentalReturned when it was already
+// returned.
+func ReturnRental(rentalId int, returnDate time.Time, storeScope int) error {
+	query := `
+		UPDATE rental SET return_date = $1, last_update = $1
+		WHERE rental_id = $2 AND return_date IS NULL
+			AND ($3 = 0 OR inventory_id IN (SELECT inventory_id FROM inventory WHERE store_id = $3))
+	`
+
+	result, err := dbRaw.DB.Exec(query, returnDate, rentalId, storeScope)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		var exists bool
+		existsQuery := `
+			SELECT EXISTS (SELECT 1 FROM rental r JOIN inventory i ON i.inventory_id = r.inventory_id
+				WHERE r.rental_id = $1 AND ($2 = 0 OR i.store_id = $2))
+		`
+		if err := dbRaw.DB.QueryRow(existsQuery, rentalId, storeScope).Scan(&exists); err != nil {
+			return err
+		}
+		if exists {
+			return ErrRentalReturned
+		}
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package repository_test
+
+import (
+	"film-rental/internal/rental/model"
+	"film-rental/internal/rental/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestCheckoutRental_Mock tests that a copy which is still rented out, or a deactivated
+// customer, cannot be checked out
+func TestCheckoutRental_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	// Set the mock database to the global db.DB
+	dbRaw.DB = mockDB
+
+	req := model.CheckoutRequest{InventoryId: 7, CustomerId: 3}
+	now := time.Now()
+
+	// Test case 1: copy is available
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM inventory i WHERE i.inventory_id = \$1 AND \(\$2 = 0 OR i.store_id = \$2\) FOR UPDATE`).
+		WithArgs(req.InventoryId, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT activebool FROM customer WHERE customer_id = \$1`).
+		WithArgs(req.CustomerId).
+		WillReturnRows(sqlmock.NewRows([]string{"activebool"}).AddRow(true))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM rental`).
+		WithArgs(req.InventoryId).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`INSERT INTO rental .* RETURNING rental_id`).
+		WithArgs(now, req.InventoryId, req.CustomerId, "staff1").
+		WillReturnRows(sqlmock.NewRows([]string{"rental_id"}).AddRow(int64(99)))
+	mock.ExpectCommit()
+
+	id, err := repository.CheckoutRental(req, "staff1", now, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if id != 99 {
+		t.Fatalf("expected ID 99, got %d", id)
+	}
+
+	// Test case 2: copy is still rented out
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM inventory i WHERE i.inventory_id = \$1 AND \(\$2 = 0 OR i.store_id = \$2\) FOR UPDATE`).
+		WithArgs(req.InventoryId, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT activebool FROM customer WHERE customer_id = \$1`).
+		WithArgs(req.CustomerId).
+		WillReturnRows(sqlmock.NewRows([]string{"activebool"}).AddRow(true))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM rental`).
+		WithArgs(req.InventoryId).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectRollback()
+
+	_, err = repository.CheckoutRental(req, "staff1", now, 1)
+	if err != repository.ErrInventoryRented {
+		t.Fatalf("expected ErrInventoryRented, got %v", err)
+	}
+
+	// Test case 3: customer has been deactivated
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FROM inventory i WHERE i.inventory_id = \$1 AND \(\$2 = 0 OR i.store_id = \$2\) FOR UPDATE`).
+		WithArgs(req.InventoryId, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery(`SELECT activebool FROM customer WHERE customer_id = \$1`).
+		WithArgs(req.CustomerId).
+		WillReturnRows(sqlmock.NewRows([]string{"activebool"}).AddRow(false))
+	mock.ExpectRollback()
+
+	_, err = repository.CheckoutRental(req, "staff1", now, 1)
+	if err != repository.ErrCustomerInactive {
+		t.Fatalf("expected ErrCustomerInactive, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestReturnRental_Mock tests returning a rental that was already returned
+func TestReturnRental_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	now := time.Now()
+	mock.ExpectExec(`UPDATE rental SET return_date = \$1`).
+		WithArgs(now, 5, 0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM rental r JOIN inventory i (.+) WHERE r.rental_id = \$1`).
+		WithArgs(5, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+	if err := repository.ReturnRental(5, now, 0); err != repository.ErrRentalReturned {
+		t.Fatalf("expected ErrRentalReturned, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestApplyLateFee tests late fee calculation for overdue rentals
+func TestApplyLateFee(t *testing.T) {
+	due := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	rental := model.Rental{DueDate: due, RentalRate: 2.99}
+
+	rental.ApplyLateFee(due.Add(-time.Hour))
+	if rental.DaysOverdue != 0 || rental.LateFee != 0 {
+		t.Fatalf("expected no late fee before due date, got %d days / %.2f", rental.DaysOverdue, rental.LateFee)
+	}
+
+	rental.ApplyLateFee(due.Add(49 * time.Hour))
+	if rental.DaysOverdue != 3 {
+		t.Fatalf("expected 3 days overdue, got %d", rental.DaysOverdue)
+	}
+	if rental.LateFee != float32(3)*2.99 {
+		t.Fatalf("expected late fee %.2f, got %.2f", float32(3)*2.99, rental.LateFee)
+	}
+
+	// Returned rentals stop accruing at the return date
+	returned := due.Add(12 * time.Hour)
+	rental.ReturnDate = &returned
+	rental.ApplyLateFee(due.Add(240 * time.Hour))
+	if rental.DaysOverdue != 1 {
+		t.Fatalf("expected 1 day overdue, got %d", rental.DaysOverdue)
+	}
+}
@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"database/sql"
+	"film-rental/internal/role/model"
+	"film-rental/internal/role/repository"
+	"film-rental/pkg/response"
+	"film-rental/validator"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func GetRoles(c *gin.Context) {
+	roles, err := repository.GetAllRoles()
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get roles", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Success", roles)
+}
+
+func GetPermissions(c *gin.Context) {
+	permissions, err := repository.GetAllPermissions()
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get permissions", err)
+		return
+	}
+	response.WriteSuccess(c, http.StatusOK, "Success", permissions)
+}
+
+func AddRole(c *gin.Context) {
+	var req model.RoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if err := validator.ValidateString(req.Name, 3, 50); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Name validation failed", err)
+		return
+	}
+
+	if err := repository.InsertRole(req, time.Now()); err != nil {
+		switch err {
+		case repository.ErrRoleExists:
+			response.WriteError(c, http.StatusConflict, "Role already exists", err)
+		case repository.ErrPermissionNotFound:
+			response.WriteError(c, http.StatusBadRequest, "Unknown permission", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to insert role", err)
+		}
+		return
+	}
+
+	// A lookup of the name before it existed may be cached as an unknown role
+	publishInvalidation()
+	response.WriteSuccess(c, http.StatusCreated, "Role created successfully", map[string]any{"name": req.Name})
+}
+
+func GrantPermission(c *gin.Context) {
+	role, permission := c.Param("name"), c.Param("permission")
+
+	if err := repository.GrantPermission(role, permission); err != nil {
+		switch err {
+		case repository.ErrRoleNotFound:
+			response.WriteError(c, http.StatusNotFound, "Role not found", err)
+		case repository.ErrPermissionNotFound:
+			response.WriteError(c, http.StatusNotFound, "Permission not found", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to grant permission", err)
+		}
+		return
+	}
+
+	publishInvalidation()
+	response.WriteSuccess(c, http.StatusOK, "Permission granted successfully", nil)
+}
+
+func RevokePermission(c *gin.Context) {
+	role, permission := c.Param("name"), c.Param("permission")
+
+	if err := repository.RevokePermission(role, permission); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Role does not have this permission", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to revoke permission", err)
+		return
+	}
+
+	publishInvalidation()
+	response.WriteSuccess(c, http.StatusOK, "Permission revoked successfully", nil)
+}
+
+// SetRoleMFA turns MFA enforcement on or off for a role. It applies from the next
+// login; sessions that are already open are not affected.
+func SetRoleMFA(c *gin.Context) {
+	var req model.RoleMFARequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := repository.SetMFARequired(c.Param("name"), *req.Required); err != nil {
+		if err == repository.ErrRoleNotFound {
+			response.WriteError(c, http.StatusNotFound, "Role not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update role", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Role MFA requirement updated successfully", map[string]any{"require_mfa": *req.Required})
+}
+
+// publishInvalidation drops this instance's cached grants and tells every other instance
+// to drop theirs. The change is already committed, so a failure to publish is logged
+// and the cache TTL bounds the staleness elsewhere.
+func publishInvalidation() {
+	if err := repository.PublishInvalidation(); err != nil {
+		log.Printf("❌ Failed to publish permission cache invalidation: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type Role struct {
+	Name        string    `gorm:"primaryKey;size:50" json:"name"`
+	Description string    `gorm:"size:255" json:"description"`
+	RequireMFA  bool      `gorm:"not null;default:false" json:"require_mfa"`
+	CreatedAt   time.Time `json:"created_at"`
+	Permissions []string  `gorm:"-" json:"permissions"`
+}
+
+type Permission struct {
+	Name        string `gorm:"primaryKey;size:100" json:"name"`
+	Description string `gorm:"size:255" json:"description"`
+}
+
+// RolePermission grants a permission to a role
+type RolePermission struct {
+	RoleName       string `gorm:"primaryKey;size:50"`
+	PermissionName string `gorm:"primaryKey;size:100"`
+}
+
+type RoleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+type RoleMFARequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"film-rental/pkg/redis"
+	"log"
+	"sync"
+	"time"
+)
+
+// invalidationChannel is the Redis channel every instance listens on to drop its
+// cached grants as soon as any instance changes a role
+const invalidationChannel = "rbac:invalidate"
+
+type cachedRole struct {
+	permissions []string
+	exists      bool
+	loadedAt    time.Time
+}
+
+// PermissionCache caches role grants from the database. Entries are dropped on every
+// invalidation message; the TTL only bounds staleness if a message is missed while
+// the Redis connection is down.
+type PermissionCache struct {
+	ttl   time.Duration
+	mu    sync.RWMutex
+	roles map[string]cachedRole
+	// generation counts invalidations, so grants loaded before one are not cached
+	generation uint64
+}
+
+// DefaultPermissionCache is the cache of this instance, invalidated directly by
+// PublishInvalidation; it is set up in main
+var DefaultPermissionCache *PermissionCache
+
+func NewPermissionCache(ttl time.Duration) *PermissionCache {
+	return &PermissionCache{ttl: ttl, roles: make(map[string]cachedRole)}
+}
+
+// RolePermissions implements model.PermissionSource
+func (c *PermissionCache) RolePermissions(role string) ([]string, bool, error) {
+	c.mu.RLock()
+	cached, ok := c.roles[role]
+	generation := c.generation
+	c.mu.RUnlock()
+	if ok && time.Since(cached.loadedAt) < c.ttl {
+		return cached.permissions, cached.exists, nil
+	}
+
+	permissions, exists, err := GetRolePermissions(role)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// An invalidation during the load may have revoked what was just read
+	c.mu.Lock()
+	if c.generation == generation {
+		c.roles[role] = cachedRole{permissions: permissions, exists: exists, loadedAt: time.Now()}
+	}
+	c.mu.Unlock()
+
+	return permissions, exists, nil
+}
+
+// Invalidate drops every cached role on this instance
+func (c *PermissionCache) Invalidate() {
+	c.mu.Lock()
+	c.roles = make(map[string]cachedRole)
+	c.generation++
+	c.mu.Unlock()
+}
+
+// Subscribe invalidates the cache whenever any instance publishes a change, until ctx
+// is cancelled
+func (c *PermissionCache) Subscribe(ctx context.Context) {
+	pubsub := redis.Rdb.Subscribe(ctx, invalidationChannel)
+	defer pubsub.Close()
+
+	// Anything cached before the subscription was active may have missed a change
+	if _, err := pubsub.Receive(ctx); err != nil {
+		log.Printf("❌ Failed to subscribe to %s: %v", invalidationChannel, err)
+	}
+	c.Invalidate()
+
+	messages := pubsub.Channel()
+	for {
+		select {
+		case <-messages:
+			c.Invalidate()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// PublishInvalidation tells every instance to drop cached grants. This instance's
+// cache is dropped at once rather than when the message comes back.
+func PublishInvalidation() error {
+	if DefaultPermissionCache != nil {
+		DefaultPermissionCache.Invalidate()
+	}
+	return redis.Rdb.Publish(redis.Ctx, invalidationChannel, time.Now().UnixNano()).Err()
+}
@@ -0,0 +1,259 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/role/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrRoleNotFound       = errors.New("role not found")
+	ErrRoleExists         = errors.New("role already exists")
+	ErrPermissionNotFound = errors.New("permission not found")
+)
+
+func GetAllRoles() ([]*model.Role, error) {
+	query := `
+		SELECT r.name, r.description, r.require_mfa, r.created_at,
+			COALESCE(array_agg(rp.permission_name ORDER BY rp.permission_name) FILTER (WHERE rp.permission_name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_name = r.name
+		GROUP BY r.name, r.description, r.require_mfa, r.created_at
+		ORDER BY r.name
+	`
+	rows, err := dbRaw.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []*model.Role
+	for rows.Next() {
+		var r model.Role
+		if err := rows.Scan(&r.Name, &r.Description, &r.RequireMFA, &r.CreatedAt, pq.Array(&r.Permissions)); err != nil {
+			continue
+		}
+		roles = append(roles, &r)
+	}
+
+	return roles, nil
+}
+
+func GetAllPermissions() ([]*model.Permission, error) {
+	rows, err := dbRaw.DB.Query(`SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []*model.Permission
+	for rows.Next() {
+		var p model.Permission
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			continue
+		}
+		permissions = append(permissions, &p)
+	}
+
+	return permissions, nil
+}
+
+// GetRolePermissions returns the permissions granted to role and whether the role exists
+func GetRolePermissions(role string) ([]string, bool, error) {
+	query := `
+		SELECT COALESCE(array_agg(rp.permission_name) FILTER (WHERE rp.permission_name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_name = r.name
+		WHERE r.name = $1
+		GROUP BY r.name
+	`
+	var permissions []string
+	err := dbRaw.DB.QueryRow(query, role).Scan(pq.Array(&permissions))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return permissions, true, nil
+}
+
+// IsMFARequired reports whether members of role must log in with a second factor
+func IsMFARequired(role string) (bool, error) {
+	var required bool
+	err := dbRaw.DB.QueryRow(`SELECT require_mfa FROM roles WHERE name = $1`, role).Scan(&required)
+	if err == sql.ErrNoRows {
+		return false, ErrRoleNotFound
+	}
+	return required, err
+}
+
+// SetMFARequired turns MFA enforcement for role on or off
+func SetMFARequired(role string, required bool) error {
+	result, err := dbRaw.DB.Exec(`UPDATE roles SET require_mfa = $1 WHERE name = $2`, required, role)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrRoleNotFound
+	}
+	return nil
+}
+
+// InsertRole creates a role together with its initial grants
+func InsertRole(req model.RoleRequest, createdAt time.Time) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`INSERT INTO roles (name, description, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`,
+		req.Name, req.Description, createdAt)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrRoleExists
+	}
+
+	for _, permission := range req.Permissions {
+		if err := grant(tx, req.Name, permission); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GrantPermission grants permission to role; granting twice is a no-op
+func GrantPermission(role string, permission string) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var roleExists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)`, role).Scan(&roleExists); err != nil {
+		return err
+	}
+	if !roleExists {
+		return ErrRoleNotFound
+	}
+
+	if err := grant(tx, role, permission); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func grant(tx *sql.Tx, role string, permission string) error {
+	result, err := tx.Exec(`
+		INSERT INTO role_permissions (role_name, permission_name)
+		SELECT $1, name FROM permissions WHERE name = $2
+		ON CONFLICT DO NOTHING`, role, permission)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		var permissionExists bool
+		if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM permissions WHERE name = $1)`, permission).Scan(&permissionExists); err != nil {
+			return err
+		}
+		if !permissionExists {
+			return ErrPermissionNotFound
+		}
+	}
+
+	return nil
+}
+
+// RevokePermission removes a grant. It returns sql.ErrNoRows when the role did not
+// have the permission.
+func RevokePermission(role string, permission string) error {
+	result, err := dbRaw.DB.Exec(`DELETE FROM role_permissions WHERE role_name = $1 AND permission_name = $2`, role, permission)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// SeedDefaults makes sure the permissions and roles defined in code exist. Grants are
+// only seeded for roles or permissions created by this call, so a grant an admin
+// revoked is not restored on the next start.
+func SeedDefaults(defaults map[string][]string) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	newRoles := make(map[string]bool)
+	newPermissions := make(map[string]bool)
+
+	for role, permissions := range defaults {
+		created, err := insertIfMissing(tx, `INSERT INTO roles (name, description, created_at) VALUES ($1, '', $2) ON CONFLICT DO NOTHING`, role, now)
+		if err != nil {
+			return err
+		}
+		newRoles[role] = created
+
+		for _, permission := range permissions {
+			if _, seen := newPermissions[permission]; seen {
+				continue
+			}
+			created, err := insertIfMissing(tx, `INSERT INTO permissions (name, description) VALUES ($1, '') ON CONFLICT DO NOTHING`, permission)
+			if err != nil {
+				return err
+			}
+			newPermissions[permission] = created
+		}
+	}
+
+	for role, permissions := range defaults {
+		for _, permission := range permissions {
+			if !newRoles[role] && !newPermissions[permission] {
+				continue
+			}
+			if _, err := tx.Exec(`INSERT INTO role_permissions (role_name, permission_name) VALUES ($1, $2) ON CONFLICT DO NOTHING`, role, permission); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertIfMissing(tx *sql.Tx, query string, args ...any) (bool, error) {
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	rowsAffected, err := result.RowsAffected()
+	return rowsAffected > 0, err
+}
@@ -0,0 +1,146 @@
+package repository_test
+
+import (
+	"database/sql/driver"
+	"film-rental/internal/role/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func TestSeedDefaults_OnlyGrantsNewRows(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	// The role already exists, so only the newly added permission is granted
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO roles`).WithArgs("admin", sqlmock.AnyArg()).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO permissions`).WithArgs("film:read").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO permissions`).WithArgs("role:read").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO role_permissions`).WithArgs("admin", "role:read").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err = repository.SeedDefaults(map[string][]string{"admin": {"film:read", "role:read"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestPermissionCache_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	cache := repository.NewPermissionCache(time.Minute)
+
+	mock.ExpectQuery(`FROM roles r`).WithArgs("manager").
+		WillReturnRows(sqlmock.NewRows([]string{"permissions"}).AddRow("{film:read,rental:read}"))
+
+	// The second lookup is served from the cache
+	for i := 0; i < 2; i++ {
+		permissions, exists, err := cache.RolePermissions("manager")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if !exists || len(permissions) != 2 {
+			t.Fatalf("expected 2 permissions for existing role, got %v (exists=%v)", permissions, exists)
+		}
+	}
+
+	// After invalidation the role is read again and now unknown
+	cache.Invalidate()
+	mock.ExpectQuery(`FROM roles r`).WithArgs("manager").
+		WillReturnRows(sqlmock.NewRows([]string{"permissions"}))
+
+	_, exists, err := cache.RolePermissions("manager")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exists {
+		t.Fatalf("expected role to be unknown after invalidation")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// invalidatingArg matches the role argument and invalidates the cache while the grants
+// are being loaded, as a concurrent role change would
+type invalidatingArg struct {
+	cache *repository.PermissionCache
+	role  string
+}
+
+func (a invalidatingArg) Match(v driver.Value) bool {
+	a.cache.Invalidate()
+	return v == a.role
+}
+
+func TestPermissionCache_InvalidatedDuringLoad_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	cache := repository.NewPermissionCache(time.Minute)
+
+	mock.ExpectQuery(`FROM roles r`).WithArgs(invalidatingArg{cache: cache, role: "manager"}).
+		WillReturnRows(sqlmock.NewRows([]string{"permissions"}).AddRow("{film:read,rental:read}"))
+	if _, _, err := cache.RolePermissions("manager"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The grants loaded before the invalidation were not cached, so the revocation is seen
+	mock.ExpectQuery(`FROM roles r`).WithArgs("manager").
+		WillReturnRows(sqlmock.NewRows([]string{"permissions"}).AddRow("{film:read}"))
+	permissions, _, err := cache.RolePermissions("manager")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(permissions) != 1 {
+		t.Fatalf("expected the revoked grant to be gone, got %v", permissions)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestSetMFARequired_UnknownRole tests that enforcing MFA on a missing role is reported
+func TestSetMFARequired_UnknownRole(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectExec(`UPDATE roles SET require_mfa = \$1 WHERE name = \$2`).
+		WithArgs(true, "ghost").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repository.SetMFARequired("ghost", true); err != repository.ErrRoleNotFound {
+		t.Fatalf("expected ErrRoleNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
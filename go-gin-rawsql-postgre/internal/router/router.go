@@ -1,42 +1,178 @@
 package router
 
 import (
+	catalogHandler "film-rental/internal/catalog/handler"
+	customerHandler "film-rental/internal/customer/handler"
+	eventHandler "film-rental/internal/event/handler"
 	filmHandler "film-rental/internal/film/handler"
+	inventoryHandler "film-rental/internal/inventory/handler"
+	paymentHandler "film-rental/internal/payment/handler"
+	rentalHandler "film-rental/internal/rental/handler"
+	roleHandler "film-rental/internal/role/handler"
 	staffHandler "film-rental/internal/staff/handler"
 	"film-rental/internal/token"
+	tokenHandler "film-rental/internal/token/handler"
 	tokenModel "film-rental/internal/token/model"
 	"film-rental/pkg/middleware"
 
 	"github.com/gin-gonic/gin"
 )
 
-func RegisterRoutes(r *gin.Engine, jwtMaker *token.JWTMaker) {
+func RegisterRoutes(r *gin.Engine, tokenMaker token.Maker) {
 	// Public routes (no authentication required)
+	r.GET("/.well-known/jwks.json", tokenHandler.GetJWKS(tokenMaker))
+
 	filmRoutes := r.Group("films")
 	{
 		filmRoutes.GET("", filmHandler.GetFilms)
 		filmRoutes.GET("/:id", filmHandler.GetFilmDetail)
 	}
 
+	languageRoutes := r.Group("languages")
+	{
+		languageRoutes.GET("", catalogHandler.GetLanguages)
+		languageRoutes.GET("/:id", catalogHandler.GetLanguageDetail)
+	}
+
+	categoryRoutes := r.Group("categories")
+	{
+		categoryRoutes.GET("", catalogHandler.GetCategories)
+		categoryRoutes.GET("/:id", catalogHandler.GetCategoryDetail)
+		categoryRoutes.GET("/:id/films", catalogHandler.GetCategoryFilms)
+	}
+
+	actorRoutes := r.Group("actors")
+	{
+		actorRoutes.GET("", catalogHandler.GetActors)
+		actorRoutes.GET("/:id", catalogHandler.GetActorDetail)
+		actorRoutes.GET("/:id/films", catalogHandler.GetActorFilms)
+	}
+
 	// Protected routes (authentication required)
-	authMiddleware := middleware.AuthMiddleware(jwtMaker)
+	authMiddleware := middleware.AuthMiddleware(tokenMaker)
+	mfaMiddleware := middleware.MFAPendingMiddleware(tokenMaker)
 
 	filmProtectedRoutes := r.Group("films").Use(authMiddleware)
 	{
 		filmProtectedRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionFilmCreate), filmHandler.AddFilm)
 		filmProtectedRoutes.PUT("/:id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), filmHandler.UpdateFilm)
 		filmProtectedRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionFilmDelete), filmHandler.DeleteFilm)
+		filmProtectedRoutes.POST("/:id/categories/:category_id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.LinkFilmCategory)
+		filmProtectedRoutes.DELETE("/:id/categories/:category_id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.UnlinkFilmCategory)
+		filmProtectedRoutes.POST("/:id/actors/:actor_id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.LinkFilmActor)
+		filmProtectedRoutes.DELETE("/:id/actors/:actor_id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.UnlinkFilmActor)
+	}
+
+	languageProtectedRoutes := r.Group("languages").Use(authMiddleware)
+	{
+		languageProtectedRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionFilmCreate), catalogHandler.AddLanguage)
+		languageProtectedRoutes.PUT("/:id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.UpdateLanguage)
+		languageProtectedRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionFilmDelete), catalogHandler.DeleteLanguage)
+	}
+
+	categoryProtectedRoutes := r.Group("categories").Use(authMiddleware)
+	{
+		categoryProtectedRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionFilmCreate), catalogHandler.AddCategory)
+		categoryProtectedRoutes.PUT("/:id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.UpdateCategory)
+		categoryProtectedRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionFilmDelete), catalogHandler.DeleteCategory)
+	}
+
+	actorProtectedRoutes := r.Group("actors").Use(authMiddleware)
+	{
+		actorProtectedRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionFilmCreate), catalogHandler.AddActor)
+		actorProtectedRoutes.PUT("/:id", middleware.RequirePermission(tokenModel.PermissionFilmUpdate), catalogHandler.UpdateActor)
+		actorProtectedRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionFilmDelete), catalogHandler.DeleteActor)
 	}
 
 	staffRoutes := r.Group("/staff").Use(authMiddleware)
 	{
 		staffRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionStaffRead), staffHandler.GetStaffs)
 		staffRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionStaffCreate), staffHandler.AddStaff)
+		staffRoutes.GET("/:id", middleware.RequirePermissionOrOwner(tokenModel.PermissionStaffRead, staffHandler.IsSelf), staffHandler.GetStaffDetail)
+		staffRoutes.PUT("/:id", middleware.RequirePermissionOrOwner(tokenModel.PermissionStaffUpdate, staffHandler.IsSelf), staffHandler.UpdateStaff)
+		staffRoutes.POST("/:id/deactivate", middleware.RequirePermission(tokenModel.PermissionStaffUpdate), staffHandler.DeactivateStaff)
+		staffRoutes.POST("/:id/activate", middleware.RequirePermission(tokenModel.PermissionStaffUpdate), staffHandler.ActivateStaff)
+		staffRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionStaffDelete), staffHandler.DeleteStaff)
+		staffRoutes.GET("/:id/picture", middleware.RequirePermissionOrOwner(tokenModel.PermissionStaffRead, staffHandler.IsSelf), staffHandler.GetStaffPicture)
+		staffRoutes.POST("/:id/picture", middleware.RequirePermissionOrOwner(tokenModel.PermissionStaffUpdate, staffHandler.IsSelf), staffHandler.UploadStaffPicture)
+		staffRoutes.DELETE("/:id/picture", middleware.RequirePermissionOrOwner(tokenModel.PermissionStaffUpdate, staffHandler.IsSelf), staffHandler.DeleteStaffPicture)
+	}
+
+	customerRoutes := r.Group("/customers").Use(authMiddleware)
+	{
+		customerRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionCustomerRead), customerHandler.GetCustomers)
+		customerRoutes.GET("/:id", middleware.RequirePermission(tokenModel.PermissionCustomerRead), customerHandler.GetCustomerDetail)
+		customerRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionCustomerCreate), customerHandler.AddCustomer)
+		customerRoutes.PUT("/:id", middleware.RequirePermission(tokenModel.PermissionCustomerUpdate), customerHandler.UpdateCustomer)
+		customerRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionCustomerDelete), customerHandler.DeactivateCustomer)
+	}
+
+	inventoryRoutes := r.Group("/inventory").Use(authMiddleware)
+	{
+		inventoryRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionInventoryRead), inventoryHandler.GetInventory)
+		inventoryRoutes.GET("/:id", middleware.RequirePermission(tokenModel.PermissionInventoryRead), inventoryHandler.GetInventoryDetail)
+		inventoryRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionInventoryCreate), inventoryHandler.AddCopies)
+		inventoryRoutes.DELETE("/:id", middleware.RequirePermission(tokenModel.PermissionInventoryDelete), inventoryHandler.RetireCopy)
+	}
+
+	rentalRoutes := r.Group("/rentals").Use(authMiddleware)
+	{
+		rentalRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionRentalRead), rentalHandler.GetRentals)
+		rentalRoutes.GET("/overdue", middleware.RequirePermission(tokenModel.PermissionRentalRead), rentalHandler.GetOverdueRentals)
+		rentalRoutes.GET("/:id", middleware.RequirePermission(tokenModel.PermissionRentalRead), rentalHandler.GetRentalDetail)
+		rentalRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionRentalCreate), rentalHandler.CheckoutRental)
+		rentalRoutes.POST("/:id/return", middleware.RequirePermission(tokenModel.PermissionRentalUpdate), rentalHandler.ReturnRental)
+	}
+
+	paymentRoutes := r.Group("/payments").Use(authMiddleware)
+	{
+		paymentRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionPaymentRead), paymentHandler.GetPayments)
+		paymentRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionPaymentCreate), paymentHandler.AddPayment)
+		paymentRoutes.POST("/refunds", middleware.RequirePermission(tokenModel.PermissionPaymentRefund), paymentHandler.RefundPayment)
+		paymentRoutes.GET("/rentals/:id/balance", middleware.RequirePermission(tokenModel.PermissionPaymentRead), paymentHandler.GetRentalBalance)
+		paymentRoutes.POST("/rentals/:id/lost", middleware.RequirePermission(tokenModel.PermissionPaymentCreate), paymentHandler.ReportRentalLost)
+		paymentRoutes.GET("/reports/revenue", middleware.RequirePermission(tokenModel.PermissionReportRead), paymentHandler.GetRevenueReport)
+	}
+
+	roleRoutes := r.Group("/roles").Use(authMiddleware)
+	{
+		roleRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionRoleRead), roleHandler.GetRoles)
+		roleRoutes.POST("", middleware.RequirePermission(tokenModel.PermissionRoleCreate), roleHandler.AddRole)
+		roleRoutes.PUT("/:name/permissions/:permission", middleware.RequirePermission(tokenModel.PermissionRoleUpdate), roleHandler.GrantPermission)
+		roleRoutes.DELETE("/:name/permissions/:permission", middleware.RequirePermission(tokenModel.PermissionRoleUpdate), roleHandler.RevokePermission)
+		roleRoutes.PUT("/:name/mfa", middleware.RequirePermission(tokenModel.PermissionRoleUpdate), roleHandler.SetRoleMFA)
+	}
+
+	permissionRoutes := r.Group("/permissions").Use(authMiddleware)
+	{
+		permissionRoutes.GET("", middleware.RequirePermission(tokenModel.PermissionRoleRead), roleHandler.GetPermissions)
+	}
+
+	eventRoutes := r.Group("/events").Use(authMiddleware)
+	{
+		eventRoutes.GET("/dlq", middleware.RequirePermission(tokenModel.PermissionEventRead), eventHandler.GetDeadLetters)
+		eventRoutes.POST("/dlq/replay", middleware.RequirePermission(tokenModel.PermissionEventReplay), eventHandler.ReplayDeadLetters)
 	}
 
 	userRoutes := r.Group("/users")
 	{
-		userRoutes.POST("/login", staffHandler.LoginStaff(jwtMaker))
-		userRoutes.POST("/refresh", staffHandler.RefreshToken(jwtMaker))
+		userRoutes.POST("/login", staffHandler.LoginStaff(tokenMaker))
+		userRoutes.POST("/login/mfa", mfaMiddleware, staffHandler.VerifyMFALogin(tokenMaker))
+		userRoutes.POST("/login/mfa/enroll", mfaMiddleware, staffHandler.EnrollMFA)
+		userRoutes.POST("/login/mfa/confirm", mfaMiddleware, staffHandler.ConfirmMFA(tokenMaker))
+		userRoutes.POST("/refresh", staffHandler.RefreshToken(tokenMaker))
+		userRoutes.POST("/password/forgot", staffHandler.ForgotPassword)
+		userRoutes.POST("/password/reset", staffHandler.ResetPassword)
+		userRoutes.POST("/logout", staffHandler.Logout(tokenMaker))
+		userRoutes.POST("/logout-all", authMiddleware, staffHandler.LogoutAll)
+		userRoutes.GET("/me/permissions", authMiddleware, staffHandler.GetMyPermissions)
+		userRoutes.POST("/me/password", authMiddleware, staffHandler.ChangeMyPassword)
+		userRoutes.POST("/me/mfa/enroll", authMiddleware, staffHandler.EnrollMFA)
+		userRoutes.POST("/me/mfa/confirm", authMiddleware, staffHandler.ConfirmMFA(tokenMaker))
+		userRoutes.DELETE("/me/mfa", authMiddleware, staffHandler.DisableMFA)
+		userRoutes.POST("/tokens/revoke", authMiddleware, middleware.RequirePermission(tokenModel.PermissionUserRevoke), staffHandler.RevokeToken(tokenMaker))
+		userRoutes.POST("/:username/revoke-tokens", authMiddleware, middleware.RequirePermission(tokenModel.PermissionUserRevoke), staffHandler.RevokeUserTokens)
+		userRoutes.POST("/:username/unlock", authMiddleware, middleware.RequirePermission(tokenModel.PermissionUserUnlock), staffHandler.UnlockAccount)
+		userRoutes.DELETE("/:username/mfa", authMiddleware, middleware.RequirePermission(tokenModel.PermissionUserUpdate), staffHandler.ResetMFA)
 	}
 }
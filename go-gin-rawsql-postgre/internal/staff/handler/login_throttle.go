@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"encoding/json"
+	"film-rental/internal/staff/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/response"
+	"film-rental/util"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const invalidCredentialsMessage = "Invalid username or password"
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// checkDummyPassword spends the same bcrypt work as a real password check so unknown
+// usernames can't be told apart by response time
+func checkDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		hash, err := util.HashPassword("not-a-real-password")
+		if err != nil {
+			log.Printf("❌ Failed to create dummy password hash: %v", err)
+			return
+		}
+		dummyHash = hash
+	})
+	_ = util.CheckPassword(password, dummyHash)
+}
+
+// rejectThrottledLogin answers 429 with Retry-After when the username or IP still has
+// to wait. It fails closed when the attempt counters are unavailable.
+func rejectThrottledLogin(c *gin.Context, username string) bool {
+	retryAfter, err := repository.LoginRetryAfter(username, c.ClientIP())
+	if err != nil {
+		response.WriteError(c, http.StatusServiceUnavailable, "Login is temporarily unavailable", err)
+		return true
+	}
+	if retryAfter <= 0 {
+		return false
+	}
+
+	writeTooManyAttempts(c, retryAfter)
+	return true
+}
+
+// rejectFailedLogin counts the failure, of a password or a second factor, and answers
+// 401 with message. Password failures use the same message whether the username exists
+// or not.
+func rejectFailedLogin(c *gin.Context, username string, message string) {
+	ip := c.ClientIP()
+	result, err := repository.RecordLoginFailure(username, ip)
+	if err != nil {
+		log.Printf("❌ Failed to record login failure for %s: %v", username, err)
+	}
+	if result.Locked {
+		logAuthEvent("account_locked", gin.H{
+			"username":       username,
+			"ip":             ip,
+			"locked_for_sec": int(result.RetryAfter.Seconds()),
+		})
+	}
+	if result.IPLocked {
+		logAuthEvent("ip_locked", gin.H{
+			"username":       username,
+			"ip":             ip,
+			"locked_for_sec": int(result.RetryAfter.Seconds()),
+		})
+	}
+
+	if result.RetryAfter > 0 {
+		c.Header("Retry-After", retryAfterSeconds(result.RetryAfter))
+	}
+	response.WriteError(c, http.StatusUnauthorized, message, nil)
+}
+
+func writeTooManyAttempts(c *gin.Context, retryAfter time.Duration) {
+	c.Header("Retry-After", retryAfterSeconds(retryAfter))
+	response.WriteError(c, http.StatusTooManyRequests, "Too many failed login attempts, try again later", nil)
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+func logAuthEvent(message string, context gin.H) {
+	data, err := json.Marshal(context)
+	if err != nil {
+		log.Printf("❌ Failed to encode auth event %s: %v", message, err)
+		return
+	}
+	if err := repository.InsertAuthEvent(message, string(data)); err != nil {
+		log.Printf("❌ Failed to record auth event %s: %v", message, err)
+	}
+}
+
+// UnlockAccount lifts a login lockout before it expires on its own
+func UnlockAccount(c *gin.Context) {
+	username := c.Param("username")
+
+	exists, err := repository.IsUsernameExists(username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to check user", err)
+		return
+	}
+	if !exists {
+		response.WriteError(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	unlocked, err := repository.UnlockAccount(username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to unlock account", err)
+		return
+	}
+
+	unlockedBy := ""
+	if payload, ok := middleware.GetAuthPayload(c); ok {
+		unlockedBy = payload.Username
+	}
+	logAuthEvent("account_unlocked", gin.H{"username": username, "unlocked_by": unlockedBy, "was_locked": unlocked})
+
+	response.WriteSuccess(c, http.StatusOK, "Account unlocked successfully", gin.H{"was_locked": unlocked})
+}
@@ -0,0 +1,398 @@
+package handler
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/base32"
+	roleRepository "film-rental/internal/role/repository"
+	staffModel "film-rental/internal/staff/model"
+	"film-rental/internal/staff/repository"
+	"film-rental/internal/token"
+	tokenModel "film-rental/internal/token/model"
+	tokenRepository "film-rental/internal/token/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/response"
+	"film-rental/pkg/secretbox"
+	"film-rental/pkg/totp"
+	"film-rental/util"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	mfa_token_timeout  = 5 * time.Minute
+	recoveryCodeCount  = 10
+	recoveryCodeLength = 10
+	defaultMFAIssuer   = "Film Rental"
+	invalidMFAMessage  = "Invalid verification code"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// startMFAChallenge answers the password step with an MFA token instead of a session
+// when the user has MFA enabled or their role requires it. It reports whether it wrote
+// the response.
+func startMFAChallenge(c *gin.Context, tokenMaker token.Maker, staffRecord *staffModel.Staff) bool {
+	enrollment, err := repository.GetMFAEnrollment(staffRecord.Username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to log in", err)
+		return true
+	}
+	enrolled := enrollment != nil && enrollment.Enabled
+
+	required := false
+	if !enrolled {
+		required, err = roleRepository.IsMFARequired(staffRecord.Role)
+		if err != nil && err != roleRepository.ErrRoleNotFound {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to log in", err)
+			return true
+		}
+		if !required {
+			return false
+		}
+	}
+
+	mfaToken, _, err := createStaffToken(tokenMaker, staffRecord, mfa_token_timeout, token.TokenTypeMFAPendingToken)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to create tokens", err)
+		return true
+	}
+
+	message := "MFA verification required"
+	if !enrolled {
+		message = "MFA enrollment required"
+	}
+	response.WriteSuccess(c, http.StatusOK, message, tokenModel.MFAChallengeResponse{
+		MFARequired:        enrolled,
+		EnrollmentRequired: !enrolled,
+		MFAToken:           mfaToken,
+		ExpiresIn:          int(mfa_token_timeout.Seconds()),
+	})
+	return true
+}
+
+// VerifyMFALogin completes a login with a TOTP or recovery code, authenticated by the
+// MFA token from the password step
+func VerifyMFALogin(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := middleware.GetAuthPayload(c)
+		if !ok {
+			response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+			return
+		}
+
+		var req tokenModel.MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+
+		if rejectThrottledLogin(c, payload.Username) {
+			return
+		}
+
+		enrollment, err := repository.GetMFAEnrollment(payload.Username)
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to get MFA enrollment", err)
+			return
+		}
+		if enrollment == nil || !enrollment.Enabled {
+			response.WriteError(c, http.StatusBadRequest, "MFA is not enabled", nil)
+			return
+		}
+
+		verified, err := verifySecondFactor(enrollment, req)
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to verify code", err)
+			return
+		}
+		if !verified {
+			rejectFailedLogin(c, payload.Username, invalidMFAMessage)
+			return
+		}
+
+		staffRecord, ok := consumeMFAToken(c, payload)
+		if !ok {
+			return
+		}
+		completeLogin(c, tokenMaker, staffRecord)
+	}
+}
+
+// EnrollMFA creates a new TOTP secret. It works with an access token, or with the MFA
+// token of a login whose role requires MFA. The secret is unused until confirmed.
+func EnrollMFA(c *gin.Context) {
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to create secret", err)
+		return
+	}
+
+	if err := repository.SaveMFASecret(payload.Username, secret, time.Now()); err != nil {
+		if err == repository.ErrMFAAlreadyEnabled {
+			response.WriteError(c, http.StatusConflict, "MFA is already enabled", err)
+			return
+		}
+		if err == secretbox.ErrNotConfigured {
+			response.WriteError(c, http.StatusServiceUnavailable, "MFA is not available on this server", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to save secret", err)
+		return
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+	response.WriteSuccess(c, http.StatusOK, "Scan the provisioning URI and confirm with a code", tokenModel.MFAEnrollmentResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(issuer, payload.Username, secret),
+	})
+}
+
+// ConfirmMFA enables MFA once the authenticator produces a valid code and returns the
+// recovery codes. With an MFA token it also completes the login.
+func ConfirmMFA(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := middleware.GetAuthPayload(c)
+		if !ok {
+			response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+			return
+		}
+
+		var req tokenModel.MFACodeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+
+		enrollment, err := repository.GetMFAEnrollment(payload.Username)
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to get MFA enrollment", err)
+			return
+		}
+		if enrollment == nil {
+			response.WriteError(c, http.StatusBadRequest, "MFA enrollment has not been started", nil)
+			return
+		}
+		if enrollment.Enabled {
+			response.WriteError(c, http.StatusConflict, "MFA is already enabled", nil)
+			return
+		}
+
+		step, valid := totp.Validate(enrollment.Secret, req.Code, time.Now())
+		if !valid {
+			response.WriteError(c, http.StatusBadRequest, invalidMFAMessage, nil)
+			return
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to create recovery codes", err)
+			return
+		}
+		if err := repository.EnableMFA(payload.Username, step, hashes, time.Now()); err != nil {
+			if err == repository.ErrMFAAlreadyEnabled {
+				response.WriteError(c, http.StatusConflict, "MFA is already enabled", err)
+				return
+			}
+			response.WriteError(c, http.StatusInternalServerError, "Failed to enable MFA", err)
+			return
+		}
+
+		result := tokenModel.MFAConfirmResponse{RecoveryCodes: codes}
+		if payload.Type == token.TokenTypeMFAPendingToken {
+			staffRecord, ok := consumeMFAToken(c, payload)
+			if !ok {
+				return
+			}
+			result.Tokens, err = issueTokens(tokenMaker, staffRecord, uuid.New())
+			if err != nil {
+				response.WriteError(c, http.StatusInternalServerError, "Failed to create tokens", err)
+				return
+			}
+			if err := repository.ClearLoginFailures(payload.Username); err != nil {
+				log.Printf("❌ Failed to clear login failures for %s: %v", payload.Username, err)
+			}
+		}
+
+		response.WriteSuccess(c, http.StatusOK, "MFA enabled successfully", result)
+	}
+}
+
+// DisableMFA turns MFA off for the caller after checking a current code. Members of a
+// role that requires MFA cannot turn it off.
+func DisableMFA(c *gin.Context) {
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	var req tokenModel.MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	required, err := roleRepository.IsMFARequired(payload.Role)
+	if err != nil && err != roleRepository.ErrRoleNotFound {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to check role", err)
+		return
+	}
+	if required {
+		response.WriteError(c, http.StatusForbidden, "MFA is required for your role", nil)
+		return
+	}
+
+	enrollment, err := repository.GetMFAEnrollment(payload.Username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get MFA enrollment", err)
+		return
+	}
+	if enrollment == nil {
+		response.WriteError(c, http.StatusBadRequest, "MFA is not enabled", nil)
+		return
+	}
+	if enrollment.Enabled {
+		verified, err := verifySecondFactor(enrollment, req)
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to verify code", err)
+			return
+		}
+		if !verified {
+			response.WriteError(c, http.StatusUnauthorized, invalidMFAMessage, nil)
+			return
+		}
+	}
+
+	if err := repository.DisableMFA(payload.Username); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to disable MFA", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "MFA disabled successfully", nil)
+}
+
+// ResetMFA lets an admin remove the MFA of a user who lost both the authenticator and
+// the recovery codes, and revokes the user's tokens. Where the role requires MFA the
+// user enrolls again at next login.
+func ResetMFA(c *gin.Context) {
+	username := c.Param("username")
+
+	if err := repository.DisableMFA(username); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "MFA is not enabled", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to reset MFA", err)
+		return
+	}
+
+	logAuthEvent("mfa_reset", gin.H{"username": username, "reset_by": middleware.AuthUsername(c)})
+
+	// Sessions opened with the old factor may belong to whoever holds it now
+	if _, err := revokeAllTokens(username); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "MFA reset but failed to revoke tokens", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "MFA reset successfully", nil)
+}
+
+// verifySecondFactor checks a TOTP code, or else a recovery code, and marks it used
+func verifySecondFactor(enrollment *staffModel.MFAEnrollment, req tokenModel.MFACodeRequest) (bool, error) {
+	if req.Code != "" {
+		step, valid := totp.Validate(enrollment.Secret, req.Code, time.Now())
+		if !valid {
+			return false, nil
+		}
+		err := repository.UseMFAStep(enrollment.Username, step)
+		if err == repository.ErrMFACodeReused {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	if req.RecoveryCode == "" {
+		return false, nil
+	}
+	codes, err := repository.GetUnusedRecoveryCodes(enrollment.Username)
+	if err != nil {
+		return false, err
+	}
+	presented := normalizeRecoveryCode(req.RecoveryCode)
+	for _, code := range codes {
+		if util.CheckPassword(presented, code.CodeHash) != nil {
+			continue
+		}
+		err := repository.UseRecoveryCode(code.ID, time.Now())
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, nil
+}
+
+// consumeMFAToken makes the MFA token single use and reloads the user, who may have been
+// deactivated since the password step
+func consumeMFAToken(c *gin.Context, payload *token.Payload) (*staffModel.Staff, bool) {
+	if err := tokenRepository.RevokeAccessToken(payload.ID, payload.ExpiredAt); err != nil {
+		log.Printf("❌ Failed to revoke MFA token of %s: %v", payload.Username, err)
+	}
+
+	staffRecord, err := repository.GetStaff(payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusUnauthorized, "User not found", err)
+			return nil, false
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to log in", err)
+		return nil, false
+	}
+	if !staffRecord.Active {
+		response.WriteError(c, http.StatusForbidden, "Account is deactivated", nil)
+		return nil, false
+	}
+	return staffRecord, true
+}
+
+// generateRecoveryCodes returns the codes to show once and their hashes to store
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw := make([]byte, recoveryCodeLength*5/8)
+		if _, err := rand.Read(raw); err != nil {
+			return nil, nil, err
+		}
+		code := strings.ToLower(recoveryCodeEncoding.EncodeToString(raw))
+
+		hash, err := util.HashPassword(code)
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, code[:recoveryCodeLength/2]+"-"+code[recoveryCodeLength/2:])
+		hashes = append(hashes, hash)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
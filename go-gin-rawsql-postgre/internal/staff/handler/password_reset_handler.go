@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	staffModel "film-rental/internal/staff/model"
+	"film-rental/internal/staff/repository"
+	"film-rental/pkg/mailer"
+	"film-rental/pkg/response"
+	"film-rental/util"
+	"film-rental/validator"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	password_reset_timeout = 30 * time.Minute
+	resetTokenBytes        = 32
+	defaultResetURL        = "http://localhost:3000/reset-password"
+
+	// Reset emails per username and requests per IP within resetRateWindow
+	resetRequestsPerUser = 3
+	resetRequestsPerIP   = 10
+	resetRateWindow      = time.Hour
+)
+
+// ForgotPassword mails a single-use reset link to the account's address. The response
+// is the same whether or not the account exists, and the mail is sent in the
+// background so the response time doesn't tell either.
+func ForgotPassword(c *gin.Context) {
+	var req staffModel.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if rejectRateLimited(c, "password_reset:ip:"+c.ClientIP(), resetRequestsPerIP) ||
+		rejectRateLimited(c, "password_reset:user:"+req.Username, resetRequestsPerUser) {
+		return
+	}
+
+	staff, err := repository.GetStaffContact(req.Username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to request password reset", err)
+		return
+	}
+	if staff != nil && staff.Email != "" {
+		if err := sendResetLink(staff); err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to request password reset", err)
+			return
+		}
+	}
+
+	response.WriteSuccess(c, http.StatusAccepted, "If the account exists, a reset link has been sent to its email address", nil)
+}
+
+// ResetPassword sets a new password with a token from a reset link. It ends every
+// session of the account and lifts a login lockout.
+func ResetPassword(c *gin.Context) {
+	var req staffModel.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if rejectRateLimited(c, "password_reset:ip:"+c.ClientIP(), resetRequestsPerIP) {
+		return
+	}
+
+	tokenHash := hashResetToken(req.Token)
+	username, err := repository.GetPasswordResetUsername(tokenHash, time.Now())
+	if err != nil {
+		if err == repository.ErrResetTokenInvalid {
+			response.WriteError(c, http.StatusBadRequest, "Reset link is invalid or has expired", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to reset password", err)
+		return
+	}
+
+	staffRecord, err := repository.GetStaff(username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to reset password", err)
+		return
+	}
+	policy := validator.CurrentPasswordPolicy()
+	if err := checkNewPassword(policy, req.NewPassword, staffRecord); err != nil {
+		writePasswordError(c, err)
+		return
+	}
+
+	hashedPassword, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to hash password", err)
+		return
+	}
+
+	// The token is consumed here, so a concurrent reset with the same link fails
+	username, err = repository.ResetPassword(tokenHash, hashedPassword, keptHistory(policy), time.Now())
+	if err != nil {
+		if err == repository.ErrResetTokenInvalid {
+			response.WriteError(c, http.StatusBadRequest, "Reset link is invalid or has expired", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to reset password", err)
+		return
+	}
+
+	if _, err := revokeAllTokens(username); err != nil {
+		log.Printf("❌ Failed to revoke tokens of %s after password reset: %v", username, err)
+	}
+	if _, err := repository.UnlockAccount(username); err != nil {
+		log.Printf("❌ Failed to unlock %s after password reset: %v", username, err)
+	}
+	logAuthEvent("password_reset", gin.H{"username": username, "ip": c.ClientIP()})
+
+	response.WriteSuccess(c, http.StatusOK, "Password reset successfully", nil)
+}
+
+// sendResetLink stores a new token and mails the link in the background
+func sendResetLink(staff *staffModel.Staff) error {
+	raw := make([]byte, resetTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	resetToken := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	if err := repository.InsertPasswordResetToken(staffModel.PasswordResetToken{
+		Username:  staff.Username,
+		TokenHash: hashResetToken(resetToken),
+		CreatedAt: now,
+		ExpiresAt: now.Add(password_reset_timeout),
+	}); err != nil {
+		return err
+	}
+
+	resetURL := os.Getenv("PASSWORD_RESET_URL")
+	if resetURL == "" {
+		resetURL = defaultResetURL
+	}
+	data := map[string]any{
+		"FirstName":        staff.FirstName,
+		"Username":         staff.Username,
+		"ResetURL":         resetURL + "?token=" + url.QueryEscape(resetToken),
+		"ExpiresInMinutes": int(password_reset_timeout.Minutes()),
+	}
+
+	go func() {
+		if mailer.Default == nil {
+			log.Printf("❌ Failed to send password reset email to %s: mailer is not configured", staff.Username)
+			return
+		}
+		if err := mailer.Default.Send("password_reset", []string{staff.Email}, data); err != nil {
+			log.Printf("❌ Failed to send password reset email to %s: %v", staff.Username, err)
+		}
+	}()
+	return nil
+}
+
+// hashResetToken hashes a reset token for storage. The token has 256 bits of entropy,
+// so a fast hash is enough and lets the token be looked up directly.
+func hashResetToken(resetToken string) string {
+	sum := sha256.Sum256([]byte(resetToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// rejectRateLimited answers 429 with Retry-After once key is over limit requests in
+// resetRateWindow. It fails closed when the counters are unavailable.
+func rejectRateLimited(c *gin.Context, key string, limit int64) bool {
+	retryAfter, err := repository.CheckRateLimit(key, limit, resetRateWindow)
+	if err != nil {
+		response.WriteError(c, http.StatusServiceUnavailable, "Service is temporarily unavailable", err)
+		return true
+	}
+	if retryAfter <= 0 {
+		return false
+	}
+
+	c.Header("Retry-After", retryAfterSeconds(retryAfter))
+	response.WriteError(c, http.StatusTooManyRequests, "Too many requests, try again later", nil)
+	return true
+}
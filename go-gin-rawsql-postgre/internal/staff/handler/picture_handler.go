@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	staffModel "film-rental/internal/staff/model"
+	"film-rental/internal/staff/repository"
+	"film-rental/pkg/blob"
+	"film-rental/pkg/imaging"
+	"film-rental/pkg/response"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	maxPictureBytes = 5 << 20
+	pictureSize     = 512
+	thumbnailSize   = 128
+)
+
+// UploadStaffPicture accepts a multipart "picture" file, validates it and stores a
+// resized picture and a thumbnail in the blob store
+func UploadStaffPicture(c *gin.Context) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	staff, err := repository.GetStaffDetail(staffId, ownerOrStoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if staff == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+
+	// Leave some room for the multipart headers around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPictureBytes+64<<10)
+	fileHeader, err := c.FormFile("picture")
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "A picture file of at most 5 MB is required", err)
+		return
+	}
+	if fileHeader.Size > maxPictureBytes {
+		response.WriteError(c, http.StatusRequestEntityTooLarge, "Picture must be at most 5 MB", nil)
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Failed to read picture", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxPictureBytes))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Failed to read picture", err)
+		return
+	}
+
+	img, err := imaging.Decode(data)
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid picture", err)
+		return
+	}
+
+	picture, err := imaging.FitJPEG(img, pictureSize)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to process picture", err)
+		return
+	}
+	thumbnail, err := imaging.FitJPEG(img, thumbnailSize)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to process picture", err)
+		return
+	}
+
+	record := staffModel.StaffPicture{
+		StaffId:       staffId,
+		PictureETag:   contentETag(picture),
+		ThumbnailETag: contentETag(thumbnail),
+		UpdatedAt:     time.Now(),
+	}
+	record.PictureKey = fmt.Sprintf("staff/%d/%s.jpg", staffId, record.PictureETag)
+	record.ThumbnailKey = fmt.Sprintf("staff/%d/%s-thumb.jpg", staffId, record.ThumbnailETag)
+
+	if err := blob.DefaultStore.Put(record.PictureKey, picture); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to store picture", err)
+		return
+	}
+	if err := blob.DefaultStore.Put(record.ThumbnailKey, thumbnail); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to store picture", err)
+		return
+	}
+
+	previous, err := repository.GetStaffPicture(staffId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff picture", err)
+		return
+	}
+	if err := repository.SaveStaffPicture(record); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to save staff picture", err)
+		return
+	}
+	if previous != nil {
+		deleteOldBlobs(previous, record.PictureKey, record.ThumbnailKey)
+	}
+
+	url := fmt.Sprintf("/staff/%d/picture", staffId)
+	response.WriteSuccess(c, http.StatusOK, "Picture uploaded successfully", gin.H{
+		"picture_url":   url,
+		"thumbnail_url": url + "?size=thumbnail",
+	})
+}
+
+// GetStaffPicture serves the picture, or its thumbnail with ?size=thumbnail. Responses
+// carry an ETag so clients can revalidate with If-None-Match instead of downloading
+// the picture again.
+func GetStaffPicture(c *gin.Context) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	staff, err := repository.GetStaffDetail(staffId, ownerOrStoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if staff == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+
+	record, err := repository.GetStaffPicture(staffId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff picture", err)
+		return
+	}
+
+	var data []byte
+	var etag string
+	switch {
+	case record != nil:
+		key, tag := record.PictureKey, record.PictureETag
+		if c.Query("size") == "thumbnail" {
+			key, tag = record.ThumbnailKey, record.ThumbnailETag
+		}
+		etag = tag
+		if etagMatches(c.GetHeader("If-None-Match"), etag) {
+			writeNotModified(c, etag)
+			return
+		}
+		data, err = blob.DefaultStore.Get(key)
+	default:
+		// Pictures stored inline before uploads existed are served as they are
+		data, err = repository.GetLegacyPicture(staffId)
+		etag = contentETag(data)
+	}
+	if err != nil && !errors.Is(err, blob.ErrNotFound) {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff picture", err)
+		return
+	}
+	if len(data) == 0 {
+		response.WriteError(c, http.StatusNotFound, "Staff has no picture", nil)
+		return
+	}
+	if etagMatches(c.GetHeader("If-None-Match"), etag) {
+		writeNotModified(c, etag)
+		return
+	}
+
+	c.Header("ETag", `"`+etag+`"`)
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, http.DetectContentType(data), data)
+}
+
+func DeleteStaffPicture(c *gin.Context) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	staff, err := repository.GetStaffDetail(staffId, ownerOrStoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if staff == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+
+	record, err := repository.GetStaffPicture(staffId)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff picture", err)
+		return
+	}
+	if err := repository.DeleteStaffPicture(staffId); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to delete staff picture", err)
+		return
+	}
+	if record != nil {
+		deleteOldBlobs(record, "", "")
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Picture deleted successfully", nil)
+}
+
+// deleteOldBlobs removes the blobs of a replaced picture. Failures only leave orphaned
+// files behind, so they are logged rather than failing the request.
+func deleteOldBlobs(old *staffModel.StaffPicture, keepKeys ...string) {
+	for _, key := range []string{old.PictureKey, old.ThumbnailKey} {
+		if containsString(keepKeys, key) {
+			continue
+		}
+		if err := blob.DefaultStore.Delete(key); err != nil {
+			log.Printf("❌ Failed to delete blob %s: %v", key, err)
+		}
+	}
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func contentETag(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:16])
+}
+
+// etagMatches implements the If-None-Match comparison, which accepts a list of
+// (possibly weak) tags or "*"
+func etagMatches(ifNoneMatch string, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || strings.Trim(candidate, `"`) == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func writeNotModified(c *gin.Context, etag string) {
+	c.Header("ETag", `"`+etag+`"`)
+	c.Header("Cache-Control", "private, no-cache")
+	c.Status(http.StatusNotModified)
+}
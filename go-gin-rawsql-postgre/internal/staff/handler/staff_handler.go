@@ -2,51 +2,74 @@ package handler
 
 import (
 	"database/sql"
+	"errors"
 	staffModel "film-rental/internal/staff/model"
 	"film-rental/internal/staff/repository"
 	"film-rental/internal/token"
 	tokenModel "film-rental/internal/token/model"
+	tokenRepository "film-rental/internal/token/repository"
+	"film-rental/pkg/middleware"
+	"film-rental/pkg/pagination"
 	"film-rental/pkg/response"
+	"film-rental/pkg/scope"
 	"film-rental/util"
 	"film-rental/validator"
-	"math"
+	"fmt"
+	"log"
 	"net/http"
+	"net/mail"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 var (
 	token_timeout_in_minute = 600
+	refresh_token_timeout   = 7 * 24 * time.Hour
 )
 
+// GetStaffs lists staff with offset pagination, or with keyset pagination when a
+// cursor query parameter is present (empty for the first page). In keyset mode
+// count=false skips the total count.
 func GetStaffs(c *gin.Context) {
 	page, err := strconv.Atoi(c.Query("page"))
-	if err != nil {
+	if err != nil || page < 1 {
 		page = 1
 	}
 
 	limit, err := strconv.Atoi(c.Query("limit"))
-	if err != nil {
+	if err != nil || limit < 1 {
 		limit = 25
 	}
 
-	staffs, count, err := repository.GetAllStaff(page, limit)
-	pageCount := math.Ceil(float64(count) / float64(limit))
+	if cursorToken, keyset := c.GetQuery("cursor"); keyset {
+		var cursor *pagination.Cursor
+		if cursorToken != "" {
+			cursor, err = pagination.DecodeCursor(cursorToken)
+			if err != nil {
+				response.WriteError(c, http.StatusBadRequest, "Invalid cursor", err)
+				return
+			}
+		}
 
-	pagination := response.PaginationMeta{
-		Limit:      limit,
-		Page:       page,
-		TotalCount: count,
-		TotalPage:  int(pageCount),
+		withCount := c.DefaultQuery("count", "true") != "false"
+		staffs, nextCursor, count, err := repository.GetStaffAfter(cursor, limit, withCount, middleware.StoreScope(c))
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to get staffs", err)
+			return
+		}
+		response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewCursorMeta(limit, nextCursor, count), staffs)
+		return
 	}
 
+	staffs, count, err := repository.GetAllStaff(page, limit, middleware.StoreScope(c))
 	if err != nil {
 		response.WriteError(c, http.StatusInternalServerError, "Failed to get staffs", err)
 		return
 	}
-	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", pagination, staffs)
+	response.WriteSuccessWithMeta(c, http.StatusOK, "Success", response.NewPaginationMeta(page, limit, count), staffs)
 }
 
 func AddStaff(c *gin.Context) {
@@ -63,6 +86,11 @@ func AddStaff(c *gin.Context) {
 		return
 	}
 
+	if !canAssignRole(c, reqStaff.Role) {
+		response.WriteError(c, http.StatusForbidden, "You cannot assign a role with permissions you do not have", nil)
+		return
+	}
+
 	// Check if username already exists
 	exists, err := repository.IsUsernameExists(reqStaff.Username)
 	if err != nil {
@@ -95,15 +123,309 @@ func AddStaff(c *gin.Context) {
 		LastUpdate: time.Now(),
 	}
 
-	id, err := repository.InsertStaff(staff)
+	id, err := repository.InsertStaff(staff, middleware.StoreScope(c), middleware.AuthUsername(c))
 	if err != nil {
+		if err == scope.ErrOutOfScope {
+			response.WriteError(c, http.StatusForbidden, "Cannot add staff to another store", err)
+			return
+		}
 		response.WriteError(c, http.StatusInternalServerError, "Failed to insert staff", err)
 		return
 	}
 	response.WriteSuccess(c, http.StatusCreated, "Success", map[string]any{"id": id})
 }
 
-func LoginStaff(jwtMaker *token.JWTMaker) gin.HandlerFunc {
+func GetStaffDetail(c *gin.Context) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	staff, err := repository.GetStaffDetail(staffId, ownerOrStoreScope(c))
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if staff == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", staff)
+}
+
+// UpdateStaff updates a staff profile. Staff editing their own profile keep their role
+// and store, and others can only change a role to or from one granting nothing they
+// lack unless they hold role:update. A role or store change revokes the staff member's
+// tokens so the new scope applies immediately.
+func UpdateStaff(c *gin.Context) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	var req staffModel.UpdateStaffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	storeScope := ownerOrStoreScope(c)
+	current, err := repository.GetStaffDetail(staffId, storeScope)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if current == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = current.Role
+	}
+	if req.StoreId == "" {
+		req.StoreId = current.StoreId
+	}
+	if middleware.IsOwnerAccess(c) && (req.Role != current.Role || req.StoreId != current.StoreId) {
+		response.WriteError(c, http.StatusForbidden, "You cannot change your own role or store", nil)
+		return
+	}
+	if !tokenModel.IsValidRole(req.Role) {
+		response.WriteError(c, http.StatusBadRequest, "Invalid role", nil)
+		return
+	}
+	// Both roles are checked so a less privileged caller can neither promote staff nor
+	// demote someone holding permissions they lack
+	if req.Role != current.Role && (!canAssignRole(c, req.Role) || !canAssignRole(c, current.Role)) {
+		response.WriteError(c, http.StatusForbidden, "You cannot assign a role with permissions you do not have", nil)
+		return
+	}
+	if _, err := mail.ParseAddress(req.Email); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid email", err)
+		return
+	}
+
+	staff := staffModel.Staff{
+		FirstName:  req.FirstName,
+		LastName:   req.LastName,
+		AddressId:  req.AddressId,
+		Email:      req.Email,
+		StoreId:    req.StoreId,
+		Role:       req.Role,
+		LastUpdate: time.Now(),
+	}
+
+	err = repository.UpdateStaff(staffId, staff, storeScope, middleware.AuthUsername(c))
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			response.WriteError(c, http.StatusNotFound, "Staff not found", err)
+		case scope.ErrOutOfScope:
+			response.WriteError(c, http.StatusForbidden, "Cannot move staff to another store", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to update staff", err)
+		}
+		return
+	}
+
+	if req.Role != current.Role || req.StoreId != current.StoreId {
+		if _, err := revokeAllTokens(current.Username); err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Staff updated but failed to revoke tokens", err)
+			return
+		}
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Staff updated successfully", nil)
+}
+
+// DeactivateStaff disables a staff member's account and revokes their tokens
+func DeactivateStaff(c *gin.Context) {
+	setStaffActive(c, false)
+}
+
+func ActivateStaff(c *gin.Context) {
+	setStaffActive(c, true)
+}
+
+func setStaffActive(c *gin.Context, active bool) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	storeScope := middleware.StoreScope(c)
+	staff, err := repository.GetStaffDetail(staffId, storeScope)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if staff == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+	if !canAssignRole(c, staff.Role) {
+		response.WriteError(c, http.StatusForbidden, "You cannot manage staff with permissions you do not have", nil)
+		return
+	}
+
+	if err := repository.SetStaffActive(staffId, active, storeScope, middleware.AuthUsername(c)); err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusNotFound, "Staff not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update staff", err)
+		return
+	}
+
+	if !active {
+		if _, err := revokeAllTokens(staff.Username); err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Staff deactivated but failed to revoke tokens", err)
+			return
+		}
+		response.WriteSuccess(c, http.StatusOK, "Staff deactivated successfully", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Staff activated successfully", nil)
+}
+
+// DeleteStaff permanently removes a staff member who has no history. Staff referenced
+// by rentals or payments must be deactivated instead.
+func DeleteStaff(c *gin.Context) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid staff ID", err)
+		return
+	}
+
+	storeScope := middleware.StoreScope(c)
+	staff, err := repository.GetStaffDetail(staffId, storeScope)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get staff detail", err)
+		return
+	}
+	if staff == nil {
+		response.WriteError(c, http.StatusNotFound, "Staff not found", nil)
+		return
+	}
+	if !canAssignRole(c, staff.Role) {
+		response.WriteError(c, http.StatusForbidden, "You cannot manage staff with permissions you do not have", nil)
+		return
+	}
+
+	if err := repository.DeleteStaff(staffId, storeScope, middleware.AuthUsername(c)); err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			response.WriteError(c, http.StatusNotFound, "Staff not found", err)
+		case repository.ErrStaffInUse:
+			response.WriteError(c, http.StatusConflict, "Staff has rental or payment history, deactivate instead", err)
+		default:
+			response.WriteError(c, http.StatusInternalServerError, "Failed to delete staff", err)
+		}
+		return
+	}
+
+	if _, err := revokeAllTokens(staff.Username); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Staff deleted but failed to revoke tokens", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Staff deleted successfully", nil)
+}
+
+// ChangeMyPassword lets the authenticated staff member change their password after
+// confirming the old one. All their sessions are revoked, so they log in again.
+func ChangeMyPassword(c *gin.Context) {
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	var req staffModel.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	staffRecord, err := repository.GetStaff(payload.Username)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			response.WriteError(c, http.StatusUnauthorized, "User not found", err)
+			return
+		}
+		response.WriteError(c, http.StatusInternalServerError, "Failed to get user", err)
+		return
+	}
+
+	if err := util.CheckPassword(req.OldPassword, staffRecord.Password); err != nil {
+		response.WriteError(c, http.StatusUnauthorized, "Old password is incorrect", err)
+		return
+	}
+
+	policy := validator.CurrentPasswordPolicy()
+	if err := checkNewPassword(policy, req.NewPassword, staffRecord); err != nil {
+		writePasswordError(c, err)
+		return
+	}
+
+	hashed, err := util.HashPassword(req.NewPassword)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to hash password", err)
+		return
+	}
+
+	if err := repository.UpdatePassword(payload.Username, hashed, keptHistory(policy)); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to update password", err)
+		return
+	}
+
+	if _, err := revokeAllTokens(payload.Username); err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Password changed but failed to revoke sessions", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Password changed successfully, please log in again", nil)
+}
+
+// IsSelf is the middleware.OwnerFunc for staff routes: staff own their own profile
+func IsSelf(c *gin.Context, payload *token.Payload) (bool, error) {
+	staffId, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		return false, nil
+	}
+
+	ownId, err := repository.GetStaffId(payload.Username)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return ownId == staffId, nil
+}
+
+// ownerOrStoreScope is the store scope for routes guarded by RequirePermissionOrOwner.
+// Staff reaching their own profile are not limited by store.
+func ownerOrStoreScope(c *gin.Context) int {
+	if middleware.IsOwnerAccess(c) {
+		return scope.AllStores
+	}
+	return middleware.StoreScope(c)
+}
+
+// canAssignRole reports whether the caller may give staff the role. Without role:update
+// only roles granting nothing the caller lacks can be handed out, so staff managers
+// cannot create or promote admins. The same check guards deactivating and deleting.
+func canAssignRole(c *gin.Context, role string) bool {
+	payload, ok := middleware.GetAuthPayload(c)
+	return ok && tokenModel.CanAssignRole(payload.Role, role)
+}
+
+func LoginStaff(tokenMaker token.Maker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var reqStaffInfo tokenModel.LoginRequest
 		if err := c.ShouldBindJSON(&reqStaffInfo); err != nil {
@@ -114,15 +436,22 @@ func LoginStaff(jwtMaker *token.JWTMaker) gin.HandlerFunc {
 			response.WriteError(c, http.StatusBadRequest, "Username validator", err)
 			return
 		}
-		if err := validator.ValidateString(reqStaffInfo.Password, 6, 30); err != nil {
+		// Only the bcrypt limit applies here: the policy may have changed since the
+		// password was set
+		if err := validator.ValidateString(reqStaffInfo.Password, 1, 72); err != nil {
 			response.WriteError(c, http.StatusBadRequest, "Password validator", err)
 			return
 		}
 
+		if rejectThrottledLogin(c, reqStaffInfo.Username) {
+			return
+		}
+
 		staffRecord, err := repository.GetStaff(reqStaffInfo.Username)
 		if err != nil {
 			if err == sql.ErrNoRows {
-				response.WriteError(c, http.StatusUnauthorized, "User not found", err)
+				checkDummyPassword(reqStaffInfo.Password)
+				rejectFailedLogin(c, reqStaffInfo.Username, invalidCredentialsMessage)
 				return
 			}
 			response.WriteError(c, http.StatusInternalServerError, "Failed to log in", err)
@@ -130,44 +459,42 @@ func LoginStaff(jwtMaker *token.JWTMaker) gin.HandlerFunc {
 		}
 
 		if err := util.CheckPassword(reqStaffInfo.Password, staffRecord.Password); err != nil {
-			response.WriteError(c, http.StatusUnauthorized, "Invalid email or password", err)
+			rejectFailedLogin(c, reqStaffInfo.Username, invalidCredentialsMessage)
 			return
 		}
+		upgradePasswordHash(staffRecord, reqStaffInfo.Password)
 
-		// Create access token (short-lived, e.g., 15 minutes)
-		accessToken, err := jwtMaker.CreateToken(
-			reqStaffInfo.Username,
-			staffRecord.Role,
-			time.Duration(token_timeout_in_minute)*time.Minute,
-			token.TokenTypeAccessToken,
-		)
-		if err != nil {
-			response.WriteError(c, http.StatusInternalServerError, "Failed to create access token", err)
+		if !staffRecord.Active {
+			response.WriteError(c, http.StatusForbidden, "Account is deactivated", nil)
 			return
 		}
 
-		// Create refresh token (long-lived, e.g., 7 days)
-		refreshToken, err := jwtMaker.CreateToken(
-			reqStaffInfo.Username,
-			staffRecord.Role,
-			7*24*time.Hour, // 7 days
-			token.TokenTypeRefreshToken,
-		)
-		if err != nil {
-			response.WriteError(c, http.StatusInternalServerError, "Failed to create refresh token", err)
+		if startMFAChallenge(c, tokenMaker, staffRecord) {
 			return
 		}
 
-		response.WriteSuccess(c, http.StatusOK, "Success", tokenModel.TokenResponse{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-			TokenType:    "Bearer",
-			ExpiresIn:    token_timeout_in_minute * 60, // in seconds
-		})
+		completeLogin(c, tokenMaker, staffRecord)
 	}
 }
 
-func RefreshToken(jwtMaker *token.JWTMaker) gin.HandlerFunc {
+// completeLogin starts a new session once every required factor has been verified
+func completeLogin(c *gin.Context, tokenMaker token.Maker, staffRecord *staffModel.Staff) {
+	tokens, err := issueTokens(tokenMaker, staffRecord, uuid.New())
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to create tokens", err)
+		return
+	}
+
+	if err := repository.ClearLoginFailures(staffRecord.Username); err != nil {
+		log.Printf("❌ Failed to clear login failures for %s: %v", staffRecord.Username, err)
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", tokens)
+}
+
+// RefreshToken rotates a refresh token: the presented token is consumed and a new
+// pair is issued in the same session. Replaying a consumed token revokes the session.
+func RefreshToken(tokenMaker token.Maker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		var req tokenModel.RefreshTokenRequest
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -181,14 +508,14 @@ func RefreshToken(jwtMaker *token.JWTMaker) gin.HandlerFunc {
 		}
 
 		// Verify the refresh token
-		payload, err := jwtMaker.VerifyToken(req.RefreshToken, token.TokenTypeRefreshToken)
+		payload, err := tokenMaker.VerifyToken(req.RefreshToken, token.TokenTypeRefreshToken)
 		if err != nil {
 			response.WriteError(c, http.StatusUnauthorized, "Invalid refresh token", err)
 			return
 		}
 
 		// Check if the user still exists in the database
-		_, err = repository.GetStaff(payload.Username)
+		staffRecord, err := repository.GetStaff(payload.Username)
 		if err != nil {
 			if err == sql.ErrNoRows {
 				response.WriteError(c, http.StatusUnauthorized, "User not found", err)
@@ -197,38 +524,271 @@ func RefreshToken(jwtMaker *token.JWTMaker) gin.HandlerFunc {
 			response.WriteError(c, http.StatusInternalServerError, "Failed to verify user", err)
 			return
 		}
+		if !staffRecord.Active {
+			response.WriteError(c, http.StatusUnauthorized, "Account is deactivated", nil)
+			return
+		}
+
+		tokens, refreshPayload, err := createTokenPair(tokenMaker, staffRecord)
+		if err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to create tokens", err)
+			return
+		}
 
-		// Create new access token
-		accessToken, err := jwtMaker.CreateToken(
-			payload.Username,
-			payload.Role,
-			time.Duration(token_timeout_in_minute)*time.Minute, // convert int to time.Duration
-			token.TokenTypeAccessToken,
-		)
+		err = tokenRepository.RotateRefreshToken(payload.ID, refreshTokenRecord(refreshPayload, uuid.Nil), time.Now())
 		if err != nil {
-			response.WriteError(c, http.StatusInternalServerError, "Failed to create access token", err)
+			switch err {
+			case tokenRepository.ErrRefreshTokenNotFound, tokenRepository.ErrRefreshTokenRevoked, tokenRepository.ErrRefreshTokenReused:
+				response.WriteError(c, http.StatusUnauthorized, "Invalid refresh token", err)
+			default:
+				response.WriteError(c, http.StatusInternalServerError, "Failed to refresh token", err)
+			}
+			return
+		}
+
+		response.WriteSuccess(c, http.StatusOK, "Token refreshed successfully", tokens)
+	}
+}
+
+// Logout revokes the session the given refresh token belongs to. Access tokens
+// already issued stay valid until they expire.
+func Logout(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req tokenModel.RefreshTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+			response.WriteError(c, http.StatusBadRequest, "Refresh token is required", err)
+			return
+		}
+
+		payload, err := tokenMaker.VerifyToken(req.RefreshToken, token.TokenTypeRefreshToken)
+		if err != nil {
+			response.WriteError(c, http.StatusUnauthorized, "Invalid refresh token", err)
+			return
+		}
+
+		if err := tokenRepository.RevokeFamily(payload.ID, time.Now()); err != nil {
+			if err == tokenRepository.ErrRefreshTokenNotFound {
+				response.WriteError(c, http.StatusUnauthorized, "Invalid refresh token", err)
+				return
+			}
+			response.WriteError(c, http.StatusInternalServerError, "Failed to log out", err)
+			return
+		}
+
+		response.WriteSuccess(c, http.StatusOK, "Logged out successfully", nil)
+	}
+}
+
+// LogoutAll revokes every session of the authenticated user, including access tokens
+// already issued
+func LogoutAll(c *gin.Context) {
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	revoked, err := revokeAllTokens(payload.Username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to log out", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Logged out from all sessions", gin.H{"revoked_tokens": revoked})
+}
+
+// GetMyPermissions returns the effective permissions of the authenticated user so
+// clients can hide actions they are not allowed to perform
+func GetMyPermissions(c *gin.Context) {
+	payload, ok := middleware.GetAuthPayload(c)
+	if !ok {
+		response.WriteError(c, http.StatusUnauthorized, "Unauthorized", nil)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Success", gin.H{
+		"username":    payload.Username,
+		"role":        payload.Role,
+		"store_id":    payload.StoreId,
+		"permissions": tokenModel.GetRolePermissions(payload.Role),
+	})
+}
+
+// RevokeUserTokens lets an admin invalidate every access and refresh token of a user
+func RevokeUserTokens(c *gin.Context) {
+	username := c.Param("username")
+
+	exists, err := repository.IsUsernameExists(username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to check user", err)
+		return
+	}
+	if !exists {
+		response.WriteError(c, http.StatusNotFound, "User not found", nil)
+		return
+	}
+
+	revoked, err := revokeAllTokens(username)
+	if err != nil {
+		response.WriteError(c, http.StatusInternalServerError, "Failed to revoke tokens", err)
+		return
+	}
+
+	response.WriteSuccess(c, http.StatusOK, "Tokens revoked successfully", gin.H{"revoked_tokens": revoked})
+}
+
+// RevokeToken lets an admin denylist a single access token until it expires
+func RevokeToken(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req tokenModel.RevokeTokenRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.WriteError(c, http.StatusBadRequest, "Invalid request body", err)
 			return
 		}
 
-		// Create new refresh token (optional - you can reuse the old one or create a new one)
-		refreshToken, err := jwtMaker.CreateToken(
-			payload.Username,
-			payload.Role,
-			7*24*time.Hour, // 7 days
-			token.TokenTypeRefreshToken,
-		)
+		payload, err := tokenMaker.VerifyToken(req.Token, token.TokenTypeAccessToken)
 		if err != nil {
-			response.WriteError(c, http.StatusInternalServerError, "Failed to create refresh token", err)
+			response.WriteError(c, http.StatusBadRequest, "Invalid access token", err)
 			return
 		}
 
-		response.WriteSuccess(c, http.StatusOK, "Token refreshed successfully", tokenModel.TokenResponse{
-			AccessToken:  accessToken,
-			RefreshToken: refreshToken,
-			TokenType:    "Bearer",
-			ExpiresIn:    token_timeout_in_minute * 60, // in seconds
-		})
+		if err := tokenRepository.RevokeAccessToken(payload.ID, payload.ExpiredAt); err != nil {
+			response.WriteError(c, http.StatusInternalServerError, "Failed to revoke token", err)
+			return
+		}
+
+		response.WriteSuccess(c, http.StatusOK, "Token revoked successfully", gin.H{"token_id": payload.ID})
+	}
+}
+
+// revokeAllTokens invalidates the access tokens issued to username so far and revokes
+// its refresh token sessions, returning the number of refresh tokens revoked
+func revokeAllTokens(username string) (int64, error) {
+	now := time.Now()
+	if err := tokenRepository.RevokeUserTokens(username, now); err != nil {
+		return 0, err
+	}
+	return tokenRepository.RevokeAllForUser(username, now)
+}
+
+// issueTokens creates an access and refresh token pair and stores the refresh token
+// as the start of a new session
+func issueTokens(tokenMaker token.Maker, staffRecord *staffModel.Staff, familyID uuid.UUID) (*tokenModel.TokenResponse, error) {
+	tokens, refreshPayload, err := createTokenPair(tokenMaker, staffRecord)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tokenRepository.InsertRefreshToken(refreshTokenRecord(refreshPayload, familyID)); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// createTokenPair creates an access and refresh token carrying the staff member's role
+// and store scope, returning the refresh token payload for the session record
+func createTokenPair(tokenMaker token.Maker, staffRecord *staffModel.Staff) (*tokenModel.TokenResponse, *token.Payload, error) {
+	accessToken, _, err := createStaffToken(tokenMaker, staffRecord, time.Duration(token_timeout_in_minute)*time.Minute, token.TokenTypeAccessToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	refreshToken, refreshPayload, err := createStaffToken(tokenMaker, staffRecord, refresh_token_timeout, token.TokenTypeRefreshToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &tokenModel.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    token_timeout_in_minute * 60, // in seconds
+	}, refreshPayload, nil
+}
+
+func createStaffToken(tokenMaker token.Maker, staffRecord *staffModel.Staff, duration time.Duration, tokenType token.TokenType) (string, *token.Payload, error) {
+	payload, err := token.NewPayload(staffRecord.Username, staffRecord.Role, duration, tokenType)
+	if err != nil {
+		return "", nil, err
+	}
+	payload.StoreId, _ = strconv.Atoi(staffRecord.StoreId)
+
+	tokenString, err := tokenMaker.CreateTokenFromPayload(payload)
+	if err != nil {
+		return "", nil, err
+	}
+	return tokenString, payload, nil
+}
+
+func refreshTokenRecord(payload *token.Payload, familyID uuid.UUID) tokenModel.RefreshToken {
+	return tokenModel.RefreshToken{
+		ID:        payload.ID,
+		FamilyID:  familyID,
+		Username:  payload.Username,
+		IssuedAt:  payload.IssuedAt,
+		ExpiresAt: payload.ExpiredAt,
+	}
+}
+
+const passwordPolicyMessage = "Password does not meet the password policy"
+
+// checkNewPassword applies the password policy, including that none of the last
+// HistorySize passwords, the current one among them, is reused
+func checkNewPassword(policy validator.PasswordPolicy, password string, staffRecord *staffModel.Staff) error {
+	if err := policy.Validate(password, staffRecord.Username); err != nil {
+		return err
+	}
+	if policy.HistorySize <= 0 {
+		return nil
+	}
+
+	previous, err := repository.GetPasswordHistory(staffRecord.Username, keptHistory(policy))
+	if err != nil {
+		return err
+	}
+	for _, hash := range append([]string{staffRecord.Password}, previous...) {
+		if util.CheckPassword(password, hash) == nil {
+			return &validator.PasswordPolicyError{
+				Violations: []string{fmt.Sprintf("must differ from the last %d passwords", policy.HistorySize)},
+			}
+		}
+	}
+	return nil
+}
+
+// keptHistory is how many replaced hashes to store: the current password is the
+// remaining entry of the policy's history
+func keptHistory(policy validator.PasswordPolicy) int {
+	return max(policy.HistorySize-1, 0)
+}
+
+func writePasswordError(c *gin.Context, err error) {
+	var policyErr *validator.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		response.WriteError(c, http.StatusBadRequest, passwordPolicyMessage, err)
+		return
+	}
+	response.WriteError(c, http.StatusInternalServerError, "Failed to check password", err)
+}
+
+// upgradePasswordHash rehashes a correct password whose hash predates a raise of
+// util.PasswordCost. Failing only postpones the upgrade to the next login.
+func upgradePasswordHash(staffRecord *staffModel.Staff, password string) {
+	if !util.NeedsRehash(staffRecord.Password) {
+		return
+	}
+
+	hashed, err := util.HashPassword(password)
+	if err != nil {
+		log.Printf("❌ Failed to rehash password of %s: %v", staffRecord.Username, err)
+		return
+	}
+	if err := repository.UpgradePasswordHash(staffRecord.Username, staffRecord.Password, hashed); err != nil {
+		log.Printf("❌ Failed to upgrade password hash of %s: %v", staffRecord.Username, err)
+		return
 	}
+	staffRecord.Password = hashed
 }
 
 // validateStaffFields validates all required staff fields
@@ -253,12 +813,12 @@ func validateStaffFields(reqStaff staffModel.CreateStaffRequest) (string, error)
 		return "Username validation failed", err
 	}
 
-	if err := validator.ValidateString(reqStaff.Password, 6, 30); err != nil {
-		return "Password validation failed", err
+	if err := validator.CurrentPasswordPolicy().Validate(reqStaff.Password, reqStaff.Username); err != nil {
+		return passwordPolicyMessage, err
 	}
 
 	if !tokenModel.IsValidRole(reqStaff.Role) {
-		return "Invalid role. Must be 'admin' or 'user'", nil
+		return "Invalid role", nil
 	}
 
 	return "", nil
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"film-rental/internal/token"
+	"film-rental/internal/token/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/middleware"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const roleStoreManager = "store_manager"
+
+// testPermissions adds a store manager role that may manage staff but not roles
+type testPermissions map[string][]string
+
+func (p testPermissions) RolePermissions(role string) ([]string, bool, error) {
+	permissions, exists := p[role]
+	return permissions, exists, nil
+}
+
+func setupStaffTestRouter(t *testing.T) (*gin.Engine, *token.JWTMaker, sqlmock.Sqlmock) {
+	gin.SetMode(gin.TestMode)
+
+	permissions := testPermissions{
+		model.RoleAdmin: model.RolePermissions[model.RoleAdmin],
+		model.RoleUser:  model.RolePermissions[model.RoleUser],
+		roleStoreManager: append([]string{model.PermissionStaffCreate, model.PermissionStaffUpdate, model.PermissionStaffDelete},
+			model.RolePermissions[model.RoleUser]...),
+	}
+	model.SetPermissionSource(permissions)
+	t.Cleanup(func() { model.SetPermissionSource(testPermissions(model.RolePermissions)) })
+
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	dbRaw.DB = mockDB
+	t.Cleanup(func() { mockDB.Close() })
+
+	jwtMaker, err := token.NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	router := gin.New()
+	staffRoutes := router.Group("/staff").Use(middleware.AuthMiddleware(jwtMaker))
+	{
+		staffRoutes.POST("", middleware.RequirePermission(model.PermissionStaffCreate), AddStaff)
+		staffRoutes.PUT("/:id", middleware.RequirePermissionOrOwner(model.PermissionStaffUpdate, IsSelf), UpdateStaff)
+		staffRoutes.POST("/:id/deactivate", middleware.RequirePermission(model.PermissionStaffUpdate), DeactivateStaff)
+		staffRoutes.DELETE("/:id", middleware.RequirePermission(model.PermissionStaffDelete), DeleteStaff)
+	}
+
+	return router, jwtMaker, mock
+}
+
+func staffRequest(t *testing.T, router *gin.Engine, jwtMaker *token.JWTMaker, method, path, role string, body map[string]any) *httptest.ResponseRecorder {
+	accessToken, err := jwtMaker.CreateToken("manager", role, time.Minute, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	req, err := http.NewRequest(method, path, bytes.NewBuffer(data))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAddStaffRoleAssignment(t *testing.T) {
+	router, jwtMaker, mock := setupStaffTestRouter(t)
+
+	newStaff := func(role string) map[string]any {
+		return map[string]any{
+			"first_name": "Jon",
+			"last_name":  "Stephens",
+			"email":      "jon@example.com",
+			"username":   "jon",
+			"password":   "Tr1cky-Lantern-Orbit",
+			"store_id":   "2",
+			"role":       role,
+		}
+	}
+
+	// A store manager cannot create an admin
+	w := staffRequest(t, router, jwtMaker, "POST", "/staff", roleStoreManager, newStaff(model.RoleAdmin))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// but can create staff with a role granting nothing they lack
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM staff WHERE username = \$1`).
+		WithArgs("jon").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	w = staffRequest(t, router, jwtMaker, "POST", "/staff", roleStoreManager, newStaff(model.RoleUser))
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+var staffColumns = []string{"staff_id", "first_name", "last_name", "address_id", "email", "store_id", "active", "username", "role", "last_update", "has_picture"}
+
+func expectStaffDetail(mock sqlmock.Sqlmock, role string) {
+	mock.ExpectQuery(`SELECT (.+) FROM staff WHERE (.+) AND staff_id = \$2`).
+		WillReturnRows(sqlmock.NewRows(staffColumns).
+			AddRow(3, "Jon", "Stephens", 4, "jon@example.com", "2", true, "jon", role, time.Now(), false))
+}
+
+func TestUpdateStaffRoleAssignment(t *testing.T) {
+	router, jwtMaker, mock := setupStaffTestRouter(t)
+
+	expectStaff := func(role string) { expectStaffDetail(mock, role) }
+	update := func(role string) map[string]any {
+		return map[string]any{
+			"first_name": "Jon",
+			"last_name":  "Stephens",
+			"email":      "jon@example.com",
+			"role":       role,
+		}
+	}
+
+	// A store manager cannot promote staff to admin
+	expectStaff(model.RoleUser)
+	w := staffRequest(t, router, jwtMaker, "PUT", "/staff/3", roleStoreManager, update(model.RoleAdmin))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// nor demote an admin
+	expectStaff(model.RoleAdmin)
+	w = staffRequest(t, router, jwtMaker, "PUT", "/staff/3", roleStoreManager, update(model.RoleUser))
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDeactivateAndDeleteStaffWithMorePermissions(t *testing.T) {
+	router, jwtMaker, mock := setupStaffTestRouter(t)
+
+	// A store manager cannot deactivate an admin
+	expectStaffDetail(mock, model.RoleAdmin)
+	w := staffRequest(t, router, jwtMaker, "POST", "/staff/3/deactivate", roleStoreManager, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// nor delete one
+	expectStaffDetail(mock, model.RoleAdmin)
+	w = staffRequest(t, router, jwtMaker, "DELETE", "/staff/3", roleStoreManager, nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	require.NoError(t, mock.ExpectationsWereMet())
+}
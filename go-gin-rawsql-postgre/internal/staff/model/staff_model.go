@@ -14,7 +14,59 @@ type Staff struct {
 	Password   string    `json:"-"`
 	Role       string    `json:"role"`
 	LastUpdate time.Time `json:"last_update"`
-	Picture    []byte    `json:"picture"`
+	Picture    []byte    `json:"-"`
+	HasPicture bool      `json:"-"`
+	PictureURL string    `json:"picture_url,omitempty"`
+}
+
+// StaffPicture records the blobs of an uploaded staff picture. Keys contain the
+// content hash, so a new upload never overwrites blobs that are still being served.
+type StaffPicture struct {
+	StaffId       int       `gorm:"primaryKey;autoIncrement:false" json:"staff_id"`
+	PictureKey    string    `gorm:"size:255;not null" json:"-"`
+	PictureETag   string    `gorm:"column:picture_etag;size:64;not null" json:"-"`
+	ThumbnailKey  string    `gorm:"size:255;not null" json:"-"`
+	ThumbnailETag string    `gorm:"column:thumbnail_etag;size:64;not null" json:"-"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// MFAEnrollment holds a staff member's TOTP secret, stored sealed with the server's
+// secret encryption key. It is only used for logins once Enabled is set by a confirmed
+// code; LastUsedStep stops a code from being replayed.
+type MFAEnrollment struct {
+	Username     string     `gorm:"primaryKey;size:50" json:"username"`
+	Secret       string     `gorm:"size:255;not null" json:"-"`
+	Enabled      bool       `gorm:"not null;default:false" json:"enabled"`
+	LastUsedStep int64      `gorm:"not null;default:0" json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+}
+
+// MFARecoveryCode is a bcrypt hashed single-use code for when the authenticator is lost
+type MFARecoveryCode struct {
+	ID       uint   `gorm:"primaryKey"`
+	Username string `gorm:"size:50;not null;index"`
+	CodeHash string `gorm:"size:100;not null"`
+	UsedAt   *time.Time
+}
+
+// PasswordResetToken is an emailed single-use reset link. Only the SHA-256 of the token
+// is stored, so the table alone cannot be used to reset passwords.
+type PasswordResetToken struct {
+	ID        uint      `gorm:"primaryKey"`
+	Username  string    `gorm:"size:50;not null;index"`
+	TokenHash string    `gorm:"size:64;not null;uniqueIndex"`
+	CreatedAt time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+}
+
+// PasswordHistory keeps earlier password hashes so they can't be reused
+type PasswordHistory struct {
+	ID           uint      `gorm:"primaryKey"`
+	Username     string    `gorm:"size:50;not null;index"`
+	PasswordHash string    `gorm:"size:100;not null"`
+	CreatedAt    time.Time `gorm:"not null"`
 }
 
 // CreateStaffRequest is used for creating new staff members
@@ -30,3 +82,31 @@ type CreateStaffRequest struct {
 	Role      string `json:"role"`
 	Picture   []byte `json:"picture"`
 }
+
+// UpdateStaffRequest is used for updating a staff profile. Role and store can only be
+// changed by users with the staff:update permission, not by the staff member themselves.
+type UpdateStaffRequest struct {
+	FirstName string `json:"first_name" binding:"required"`
+	LastName  string `json:"last_name" binding:"required"`
+	AddressId int    `json:"address_id"`
+	Email     string `json:"email" binding:"required"`
+	StoreId   string `json:"store_id"`
+	Role      string `json:"role"`
+}
+
+// ForgotPasswordRequest asks for a reset link to be mailed to the account's address
+type ForgotPasswordRequest struct {
+	Username string `json:"username" binding:"required"`
+}
+
+// ResetPasswordRequest sets a new password with the token from the reset link
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ChangePasswordRequest is used by staff to change their own password
+type ChangePasswordRequest struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"errors"
+	"film-rental/pkg/redis"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	loginFailuresKeyPrefix = "login:failures:"
+	loginCooldownKeyPrefix = "login:cooldown:"
+	loginLockoutKeyPrefix  = "login:lockout:"
+)
+
+const (
+	// Failures are forgotten once no new failure happened for loginFailureWindow
+	loginFailureWindow = 15 * time.Minute
+
+	// Past these failure counts every further attempt has to wait, doubling from
+	// loginBaseDelay up to loginMaxDelay
+	userDelayAfterFailures = 2
+	ipDelayAfterFailures   = 10
+	loginBaseDelay         = time.Second
+	loginMaxDelay          = 30 * time.Second
+
+	// A username is locked for loginLockoutDuration after maxUserFailures failures.
+	// IPs get a higher budget since many users can share one address.
+	maxUserFailures      = 5
+	maxIPFailures        = 20
+	loginLockoutDuration = 15 * time.Minute
+)
+
+// LoginAttemptResult describes the throttling state after a failed login
+type LoginAttemptResult struct {
+	// Locked and IPLocked are true only for the failure that locked the account or the
+	// IP, so each lockout is audited once
+	Locked     bool
+	IPLocked   bool
+	RetryAfter time.Duration
+}
+
+func userKey(username string) string { return "user:" + username }
+func ipKey(ip string) string         { return "ip:" + ip }
+
+// LoginRetryAfter returns how long the username and IP must wait before the next login
+// attempt, or zero when an attempt is allowed
+func LoginRetryAfter(username string, ip string) (time.Duration, error) {
+	keys := []string{
+		loginLockoutKeyPrefix + userKey(username),
+		loginLockoutKeyPrefix + ipKey(ip),
+		loginCooldownKeyPrefix + userKey(username),
+		loginCooldownKeyPrefix + ipKey(ip),
+	}
+
+	pipe := redis.Rdb.Pipeline()
+	ttls := make([]*goredis.DurationCmd, len(keys))
+	for i, key := range keys {
+		ttls[i] = pipe.PTTL(redis.Ctx, key)
+	}
+	if _, err := pipe.Exec(redis.Ctx); err != nil && !errors.Is(err, goredis.Nil) {
+		return 0, err
+	}
+
+	var retryAfter time.Duration
+	for _, ttl := range ttls {
+		// Missing keys report a negative TTL
+		if ttl.Val() > retryAfter {
+			retryAfter = ttl.Val()
+		}
+	}
+	return retryAfter, nil
+}
+
+// RecordLoginFailure counts a failed attempt for the username and the IP and applies
+// the progressive delay or the lockout it earns
+func RecordLoginFailure(username string, ip string) (LoginAttemptResult, error) {
+	userFailures, err := incrementFailures(userKey(username))
+	if err != nil {
+		return LoginAttemptResult{}, err
+	}
+	ipFailures, err := incrementFailures(ipKey(ip))
+	if err != nil {
+		return LoginAttemptResult{}, err
+	}
+
+	result := LoginLockout(userFailures, ipFailures)
+	if result.Locked {
+		if err := redis.Rdb.Set(redis.Ctx, loginLockoutKeyPrefix+userKey(username), 1, loginLockoutDuration).Err(); err != nil {
+			return LoginAttemptResult{}, err
+		}
+	}
+	if result.IPLocked {
+		if err := redis.Rdb.Set(redis.Ctx, loginLockoutKeyPrefix+ipKey(ip), 1, loginLockoutDuration).Err(); err != nil {
+			return LoginAttemptResult{}, err
+		}
+	}
+	if result.RetryAfter > 0 {
+		return result, nil
+	}
+
+	userDelay := LoginDelay(userFailures, userDelayAfterFailures)
+	if userDelay > 0 {
+		if err := redis.Rdb.Set(redis.Ctx, loginCooldownKeyPrefix+userKey(username), 1, userDelay).Err(); err != nil {
+			return LoginAttemptResult{}, err
+		}
+	}
+	ipDelay := LoginDelay(ipFailures, ipDelayAfterFailures)
+	if ipDelay > 0 {
+		if err := redis.Rdb.Set(redis.Ctx, loginCooldownKeyPrefix+ipKey(ip), 1, ipDelay).Err(); err != nil {
+			return LoginAttemptResult{}, err
+		}
+	}
+	result.RetryAfter = max(userDelay, ipDelay)
+	return result, nil
+}
+
+// LoginLockout returns the lockouts the username's and the IP's failure counts earn
+func LoginLockout(userFailures int64, ipFailures int64) LoginAttemptResult {
+	var result LoginAttemptResult
+	if userFailures >= maxUserFailures {
+		result.Locked = true
+		result.RetryAfter = loginLockoutDuration
+	}
+	if ipFailures >= maxIPFailures {
+		result.IPLocked = true
+		result.RetryAfter = loginLockoutDuration
+	}
+	return result
+}
+
+// LoginDelay is the wait imposed after the given number of failures: nothing up to
+// the threshold, then doubling from loginBaseDelay up to loginMaxDelay
+func LoginDelay(failures int64, threshold int64) time.Duration {
+	if failures <= threshold {
+		return 0
+	}
+	delay := loginBaseDelay
+	for i := threshold + 1; i < failures && delay < loginMaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, loginMaxDelay)
+}
+
+// ClearLoginFailures resets the username's counters after a successful login. The IP
+// counters are left alone so logging into one account doesn't reset guessing at others.
+func ClearLoginFailures(username string) error {
+	return redis.Rdb.Del(redis.Ctx,
+		loginFailuresKeyPrefix+userKey(username),
+		loginCooldownKeyPrefix+userKey(username),
+	).Err()
+}
+
+// UnlockAccount lifts a username's lockout and clears its failure counters. It reports
+// whether the username was locked.
+func UnlockAccount(username string) (bool, error) {
+	unlocked, err := redis.Rdb.Del(redis.Ctx, loginLockoutKeyPrefix+userKey(username)).Result()
+	if err != nil {
+		return false, err
+	}
+	if err := ClearLoginFailures(username); err != nil {
+		return false, err
+	}
+	return unlocked > 0, nil
+}
+
+// incrementFailures bumps a failure counter and restarts its window
+func incrementFailures(key string) (int64, error) {
+	var incr *goredis.IntCmd
+	_, err := redis.Rdb.TxPipelined(redis.Ctx, func(pipe goredis.Pipeliner) error {
+		incr = pipe.Incr(redis.Ctx, loginFailuresKeyPrefix+key)
+		pipe.Expire(redis.Ctx, loginFailuresKeyPrefix+key, loginFailureWindow)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
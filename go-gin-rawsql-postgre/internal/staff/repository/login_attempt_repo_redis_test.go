@@ -0,0 +1,49 @@
+package repository_test
+
+import (
+	"film-rental/internal/staff/repository"
+	"testing"
+	"time"
+)
+
+// TestLoginDelay tests that the delay starts past the threshold, doubles and is capped
+func TestLoginDelay(t *testing.T) {
+	tests := []struct {
+		failures int64
+		expected time.Duration
+	}{
+		{1, 0},
+		{2, 0},
+		{3, time.Second},
+		{4, 2 * time.Second},
+		{6, 8 * time.Second},
+		{20, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if delay := repository.LoginDelay(tt.failures, 2); delay != tt.expected {
+			t.Errorf("LoginDelay(%d, 2) = %v, expected %v", tt.failures, delay, tt.expected)
+		}
+	}
+}
+
+// TestLoginLockout tests that username and IP lockouts are reported separately
+func TestLoginLockout(t *testing.T) {
+	tests := []struct {
+		name         string
+		userFailures int64
+		ipFailures   int64
+		expected     repository.LoginAttemptResult
+	}{
+		{"below both limits", 4, 19, repository.LoginAttemptResult{}},
+		{"username locked", 5, 5, repository.LoginAttemptResult{Locked: true, RetryAfter: 15 * time.Minute}},
+		{"IP locked while guessing many usernames", 1, 20, repository.LoginAttemptResult{IPLocked: true, RetryAfter: 15 * time.Minute}},
+		{"both locked", 5, 20, repository.LoginAttemptResult{Locked: true, IPLocked: true, RetryAfter: 15 * time.Minute}},
+	}
+
+	for _, tt := range tests {
+		if result := repository.LoginLockout(tt.userFailures, tt.ipFailures); result != tt.expected {
+			t.Errorf("%s: LoginLockout(%d, %d) = %+v, expected %+v", tt.name, tt.userFailures, tt.ipFailures, result, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	model "film-rental/internal/staff/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/secretbox"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMFAAlreadyEnabled  = errors.New("mfa is already enabled")
+	ErrMFACodeReused      = errors.New("mfa code was already used")
+	ErrMFASecretNotSealed = errors.New("mfa secret is not sealed")
+)
+
+// sealedSecretPrefix marks TOTP secrets sealed with the server's secret encryption key.
+// Rows from before secrets were sealed are sealed by EncryptMFASecrets on startup.
+const sealedSecretPrefix = "sealed:"
+
+func sealMFASecret(secret string) (string, error) {
+	if secretbox.Default == nil {
+		return "", secretbox.ErrNotConfigured
+	}
+	sealed, err := secretbox.Default.Seal([]byte(secret))
+	if err != nil {
+		return "", err
+	}
+	return sealedSecretPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func openMFASecret(stored string) (string, error) {
+	encoded, ok := strings.CutPrefix(stored, sealedSecretPrefix)
+	if !ok {
+		return "", ErrMFASecretNotSealed
+	}
+	if secretbox.Default == nil {
+		return "", secretbox.ErrNotConfigured
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", secretbox.ErrMalformed
+	}
+	secret, err := secretbox.Default.Open(sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// GetMFAEnrollment returns the user's enrollment, or nil when there is none
+func GetMFAEnrollment(username string) (*model.MFAEnrollment, error) {
+	var e model.MFAEnrollment
+	err := dbRaw.DB.QueryRow(`
+		SELECT username, secret, enabled, last_used_step, created_at, confirmed_at
+		FROM mfa_enrollments WHERE username = $1
+	`, username).Scan(&e.Username, &e.Secret, &e.Enabled, &e.LastUsedStep, &e.CreatedAt, &e.ConfirmedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if e.Secret, err = openMFASecret(e.Secret); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// SaveMFASecret starts an enrollment, replacing an unconfirmed one. An enabled
+// enrollment has to be disabled first.
+func SaveMFASecret(username string, secret string, createdAt time.Time) error {
+	sealed, err := sealMFASecret(secret)
+	if err != nil {
+		return err
+	}
+
+	result, err := dbRaw.DB.Exec(`
+		INSERT INTO mfa_enrollments (username, secret, enabled, last_used_step, created_at)
+		VALUES ($1, $2, false, 0, $3)
+		ON CONFLICT (username) DO UPDATE
+			SET secret = EXCLUDED.secret, last_used_step = 0, created_at = EXCLUDED.created_at, confirmed_at = NULL
+			WHERE mfa_enrollments.enabled = false
+	`, username, sealed, createdAt)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+	return nil
+}
+
+// EnableMFA confirms the enrollment with the step of the verifying code and replaces
+// the recovery codes
+func EnableMFA(username string, step int64, recoveryCodeHashes []string, confirmedAt time.Time) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE mfa_enrollments SET enabled = true, last_used_step = $1, confirmed_at = $2
+		WHERE username = $3 AND enabled = false
+	`, step, confirmedAt, username)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrMFAAlreadyEnabled
+	}
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE username = $1`, username); err != nil {
+		return err
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.Exec(`INSERT INTO mfa_recovery_codes (username, code_hash) VALUES ($1, $2)`, username, hash); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseMFAStep records that the code of step was used. Each code is accepted once, and
+// never one older than the last accepted.
+func UseMFAStep(username string, step int64) error {
+	result, err := dbRaw.DB.Exec(`
+		UPDATE mfa_enrollments SET last_used_step = $1
+		WHERE username = $2 AND enabled = true AND last_used_step < $1
+	`, step, username)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrMFACodeReused
+	}
+	return nil
+}
+
+// GetUnusedRecoveryCodes returns the recovery codes that can still be redeemed
+func GetUnusedRecoveryCodes(username string) ([]*model.MFARecoveryCode, error) {
+	rows, err := dbRaw.DB.Query(`
+		SELECT id, username, code_hash FROM mfa_recovery_codes
+		WHERE username = $1 AND used_at IS NULL
+		ORDER BY id
+	`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []*model.MFARecoveryCode
+	for rows.Next() {
+		var code model.MFARecoveryCode
+		if err := rows.Scan(&code.ID, &code.Username, &code.CodeHash); err != nil {
+			return nil, err
+		}
+		codes = append(codes, &code)
+	}
+	return codes, rows.Err()
+}
+
+// UseRecoveryCode redeems a recovery code; a code that was already used returns
+// sql.ErrNoRows
+func UseRecoveryCode(id uint, usedAt time.Time) error {
+	result, err := dbRaw.DB.Exec(`UPDATE mfa_recovery_codes SET used_at = $1 WHERE id = $2 AND used_at IS NULL`, usedAt, id)
+	if err != nil {
+		return err
+	}
+	return requireRowAffected(result)
+}
+
+// DisableMFA removes the enrollment and its recovery codes
+func DisableMFA(username string) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE username = $1`, username); err != nil {
+		return err
+	}
+	result, err := tx.Exec(`DELETE FROM mfa_enrollments WHERE username = $1`, username)
+	if err != nil {
+		return err
+	}
+	if err := requireRowAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// EncryptMFASecrets seals the secrets stored before secrets were sealed and returns how
+// many it sealed. Without a secret encryption key it fails only when there are
+// enrollments, which could be neither sealed nor opened.
+func EncryptMFASecrets() (int, error) {
+	if secretbox.Default == nil {
+		var enrolled bool
+		if err := dbRaw.DB.QueryRow(`SELECT EXISTS (SELECT 1 FROM mfa_enrollments)`).Scan(&enrolled); err != nil {
+			return 0, err
+		}
+		if enrolled {
+			return 0, secretbox.ErrNotConfigured
+		}
+		return 0, nil
+	}
+
+	rows, err := dbRaw.DB.Query(`SELECT username, secret FROM mfa_enrollments WHERE secret NOT LIKE $1`, sealedSecretPrefix+"%")
+	if err != nil {
+		return 0, err
+	}
+	plain := map[string]string{}
+	for rows.Next() {
+		var username, secret string
+		if err := rows.Scan(&username, &secret); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		plain[username] = secret
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	sealedCount := 0
+	for username, secret := range plain {
+		sealed, err := sealMFASecret(secret)
+		if err != nil {
+			return sealedCount, err
+		}
+		// A secret replaced in the meantime was sealed by SaveMFASecret
+		result, err := dbRaw.DB.Exec(`UPDATE mfa_enrollments SET secret = $1 WHERE username = $2 AND secret = $3`, sealed, username, secret)
+		if err != nil {
+			return sealedCount, err
+		}
+		if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected > 0 {
+			sealedCount++
+		}
+	}
+	return sealedCount, nil
+}
@@ -0,0 +1,195 @@
+package repository_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"film-rental/internal/staff/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/secretbox"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+var enrollmentColumns = []string{"username", "secret", "enabled", "last_used_step", "created_at", "confirmed_at"}
+
+func setupSecretBox(t *testing.T) {
+	box, err := secretbox.New(bytes.Repeat([]byte{3}, secretbox.KeySize))
+	if err != nil {
+		t.Fatalf("failed to create box: %v", err)
+	}
+	secretbox.Default = box
+	t.Cleanup(func() { secretbox.Default = nil })
+}
+
+// sealedSecret matches a sealed secret and keeps it
+type sealedSecret struct {
+	value *string
+}
+
+func (s sealedSecret) Match(v driver.Value) bool {
+	str, ok := v.(string)
+	*s.value = str
+	return ok && strings.HasPrefix(str, "sealed:") && !strings.Contains(str, "JBSWY3DPEHPK3PXP")
+}
+
+// TestSaveMFASecret_AlreadyEnabled tests that an enabled enrollment is never replaced
+func TestSaveMFASecret_AlreadyEnabled(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+	setupSecretBox(t)
+
+	mock.ExpectExec(`INSERT INTO mfa_enrollments`).
+		WithArgs("mike", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repository.SaveMFASecret("mike", "SECRET", time.Now()); err != repository.ErrMFAAlreadyEnabled {
+		t.Fatalf("expected ErrMFAAlreadyEnabled, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestUseMFAStep_Reused tests that a code is refused once its step has been used
+func TestUseMFAStep_Reused(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectExec(`UPDATE mfa_enrollments SET last_used_step = \$1`).
+		WithArgs(int64(100), "mike").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE mfa_enrollments SET last_used_step = \$1`).
+		WithArgs(int64(100), "mike").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	if err := repository.UseMFAStep("mike", 100); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := repository.UseMFAStep("mike", 100); err != repository.ErrMFACodeReused {
+		t.Fatalf("expected ErrMFACodeReused, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestMFASecret_SealedAtRest tests that secrets are stored sealed and read back in
+// plain, and that an unsealed secret is refused
+func TestMFASecret_SealedAtRest(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+	setupSecretBox(t)
+
+	var stored string
+	mock.ExpectExec(`INSERT INTO mfa_enrollments`).
+		WithArgs("mike", sealedSecret{&stored}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if err := repository.SaveMFASecret("mike", "JBSWY3DPEHPK3PXP", time.Now()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT username, secret, (.+) FROM mfa_enrollments WHERE username = \$1`).
+		WithArgs("mike").
+		WillReturnRows(sqlmock.NewRows(enrollmentColumns).AddRow("mike", stored, true, 0, time.Now(), nil))
+
+	enrollment, err := repository.GetMFAEnrollment("mike")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if enrollment.Secret != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("unexpected secret %q", enrollment.Secret)
+	}
+
+	mock.ExpectQuery(`SELECT username, secret, (.+) FROM mfa_enrollments WHERE username = \$1`).
+		WithArgs("mike").
+		WillReturnRows(sqlmock.NewRows(enrollmentColumns).AddRow("mike", "JBSWY3DPEHPK3PXP", true, 0, time.Now(), nil))
+
+	if _, err := repository.GetMFAEnrollment("mike"); err != repository.ErrMFASecretNotSealed {
+		t.Fatalf("expected ErrMFASecretNotSealed, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestEncryptMFASecrets_Mock tests that secrets stored before encryption get sealed
+func TestEncryptMFASecrets_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+	setupSecretBox(t)
+
+	mock.ExpectQuery(`SELECT username, secret FROM mfa_enrollments WHERE secret NOT LIKE \$1`).
+		WithArgs("sealed:%").
+		WillReturnRows(sqlmock.NewRows([]string{"username", "secret"}).AddRow("mike", "JBSWY3DPEHPK3PXP"))
+	var stored string
+	mock.ExpectExec(`UPDATE mfa_enrollments SET secret = \$1 WHERE username = \$2 AND secret = \$3`).
+		WithArgs(sealedSecret{&stored}, "mike", "JBSWY3DPEHPK3PXP").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	sealed, err := repository.EncryptMFASecrets()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sealed != 1 {
+		t.Fatalf("expected 1 sealed secret, got %d", sealed)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestEncryptMFASecrets_WithoutKey_Mock tests that a missing encryption key is only an
+// error once there are enrollments
+func TestEncryptMFASecrets_WithoutKey_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM mfa_enrollments\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	if _, err := repository.EncryptMFASecrets(); err != nil {
+		t.Fatalf("unexpected error without enrollments: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT EXISTS \(SELECT 1 FROM mfa_enrollments\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	if _, err := repository.EncryptMFASecrets(); err != secretbox.ErrNotConfigured {
+		t.Fatalf("expected ErrNotConfigured with enrollments, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"database/sql"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+)
+
+// GetPasswordHistory returns up to limit earlier password hashes, newest first. The
+// current password is not included.
+func GetPasswordHistory(username string, limit int) ([]string, error) {
+	if limit <= 0 {
+		return nil, nil
+	}
+
+	rows, err := dbRaw.DB.Query(`
+		SELECT password_hash FROM password_history
+		WHERE username = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, username, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
+// archivePassword copies the current hash into the history and trims it to keep entries
+func archivePassword(tx *sql.Tx, username string, keep int, now time.Time) error {
+	if keep > 0 {
+		if _, err := tx.Exec(`
+			INSERT INTO password_history (username, password_hash, created_at)
+			SELECT username, password, $2 FROM staff WHERE username = $1 AND password IS NOT NULL
+		`, username, now); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`
+		DELETE FROM password_history
+		WHERE username = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE username = $1 ORDER BY id DESC LIMIT $2
+		)
+	`, username, keep)
+	return err
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	model "film-rental/internal/staff/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+)
+
+var ErrResetTokenInvalid = errors.New("password reset token is invalid or expired")
+
+// GetStaffContact returns the name and email of an active staff member, or nil when
+// there is no such account
+func GetStaffContact(username string) (*model.Staff, error) {
+	var staff model.Staff
+	err := dbRaw.DB.QueryRow(`
+		SELECT username, first_name, COALESCE(email, '') FROM staff WHERE username = $1 AND active = true
+	`, username).Scan(&staff.Username, &staff.FirstName, &staff.Email)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &staff, nil
+}
+
+// InsertPasswordResetToken stores a new reset token. Links sent earlier stop working,
+// so only the newest email can be used.
+func InsertPasswordResetToken(t model.PasswordResetToken) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE username = $2 AND used_at IS NULL`,
+		t.CreatedAt, t.Username); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO password_reset_tokens (username, token_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`, t.Username, t.TokenHash, t.CreatedAt, t.ExpiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetPasswordResetUsername returns the account of a token that can still be used
+func GetPasswordResetUsername(tokenHash string, now time.Time) (string, error) {
+	var username string
+	err := dbRaw.DB.QueryRow(`
+		SELECT username FROM password_reset_tokens
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+	`, tokenHash, now).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", ErrResetTokenInvalid
+	}
+	return username, err
+}
+
+// ResetPassword consumes the token and sets the new password hash in one transaction,
+// returning the username. Unknown, used and expired tokens return ErrResetTokenInvalid.
+// The replaced hash moves to the password history like in UpdatePassword.
+func ResetPassword(tokenHash string, hashedPassword string, keepHistory int, now time.Time) (string, error) {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var username string
+	err = tx.QueryRow(`
+		UPDATE password_reset_tokens SET used_at = $1
+		WHERE token_hash = $2 AND used_at IS NULL AND expires_at > $1
+		RETURNING username
+	`, now, tokenHash).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", ErrResetTokenInvalid
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if err := archivePassword(tx, username, keepHistory, now); err != nil {
+		return "", err
+	}
+
+	result, err := tx.Exec(`UPDATE staff SET password = $1, last_update = $2 WHERE username = $3 AND active = true`,
+		hashedPassword, now, username)
+	if err != nil {
+		return "", err
+	}
+	if err := requireRowAffected(result); err != nil {
+		if err == sql.ErrNoRows {
+			// The account was deactivated or removed after the link was sent
+			return "", ErrResetTokenInvalid
+		}
+		return "", err
+	}
+
+	return username, tx.Commit()
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"film-rental/internal/staff/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// TestResetPassword tests that a valid token is consumed together with the password
+// change, and that the replaced hash goes to the history
+func TestResetPassword(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE password_reset_tokens SET used_at = \$1`).
+		WithArgs(now, "tokenhash").
+		WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow("mike"))
+	mock.ExpectExec(`INSERT INTO password_history`).
+		WithArgs("mike", now).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM password_history`).
+		WithArgs("mike", 4).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`UPDATE staff SET password = \$1`).
+		WithArgs("newhash", now, "mike").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	username, err := repository.ResetPassword("tokenhash", "newhash", 4, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if username != "mike" {
+		t.Errorf("expected mike, got %s", username)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestResetPassword_InvalidToken tests that used or expired tokens leave the password alone
+func TestResetPassword_InvalidToken(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE password_reset_tokens SET used_at = \$1`).
+		WithArgs(sqlmock.AnyArg(), "tokenhash").
+		WillReturnRows(sqlmock.NewRows([]string{"username"}))
+	mock.ExpectRollback()
+
+	if _, err := repository.ResetPassword("tokenhash", "newhash", 4, time.Now()); err != repository.ErrResetTokenInvalid {
+		t.Fatalf("expected ErrResetTokenInvalid, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"film-rental/pkg/redis"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const rateLimitKeyPrefix = "ratelimit:"
+
+// CheckRateLimit counts a request against a fixed window of limit requests per window
+// and returns how long to wait when the limit is exceeded, or zero
+func CheckRateLimit(key string, limit int64, window time.Duration) (time.Duration, error) {
+	key = rateLimitKeyPrefix + key
+
+	var count *goredis.IntCmd
+	var ttl *goredis.DurationCmd
+	_, err := redis.Rdb.TxPipelined(redis.Ctx, func(pipe goredis.Pipeliner) error {
+		// Only the first request of a window creates the key and its expiry
+		pipe.SetNX(redis.Ctx, key, 0, window)
+		count = pipe.Incr(redis.Ctx, key)
+		ttl = pipe.PTTL(redis.Ctx, key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if count.Val() <= limit {
+		return 0, nil
+	}
+	return max(ttl.Val(), time.Second), nil
+}
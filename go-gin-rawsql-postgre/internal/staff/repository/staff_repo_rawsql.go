@@ -1,11 +1,26 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	model "film-rental/internal/staff/model"
 	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/kafka"
+	"film-rental/pkg/pagination"
+	"film-rental/pkg/scope"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
 )
 
-const queryColumns = "staff_id, first_name, last_name, address_id, email, store_id, active, username, role, last_update, picture"
+var ErrStaffInUse = errors.New("staff is referenced by rentals, payments or a store")
+
+// The picture itself is served by GET /staff/:id/picture, so listings only learn
+// whether there is one
+const queryColumns = `staff_id, first_name, last_name, address_id, email, store_id, active, username, role, last_update,
+	(picture IS NOT NULL OR EXISTS (SELECT 1 FROM staff_pictures sp WHERE sp.staff_id = staff.staff_id)) AS has_picture`
 
 func scanStaffRow(scanner interface {
 	Scan(dest ...any) error
@@ -14,22 +29,32 @@ func scanStaffRow(scanner interface {
 	err := scanner.Scan(
 		&f.StaffId, &f.FirstName, &f.LastName, &f.AddressId,
 		&f.Email, &f.StoreId, &f.Active,
-		&f.Username, &f.Role, &f.LastUpdate, &f.Picture,
+		&f.Username, &f.Role, &f.LastUpdate, &f.HasPicture,
 	)
+	if f.HasPicture {
+		f.PictureURL = fmt.Sprintf("/staff/%d/picture", f.StaffId)
+	}
 	return &f, err
 }
 
-func GetAllStaff(page int, limit int) ([]*model.Staff, int, error) {
-	queryStr := `SELECT ` + queryColumns + ` FROM staff LIMIT $1 OFFSET $2`
+// deletedColumns matches queryColumns for a row being deleted, whose pictures go with it
+const deletedColumns = `staff_id, first_name, last_name, address_id, email, store_id, active, username, role, last_update, false AS has_picture`
+
+// storeFilter restricts staff to storeScope; scope.AllStores (0) disables it
+const storeFilter = `($1 = 0 OR store_id = $1)`
+
+// GetAllStaff lists staff of the stores visible within storeScope
+func GetAllStaff(page int, limit int, storeScope int) ([]*model.Staff, int, error) {
+	queryStr := `SELECT ` + queryColumns + ` FROM staff WHERE ` + storeFilter + ` ORDER BY staff_id LIMIT $2 OFFSET $3`
 
-	rows, err := dbRaw.DB.Query(queryStr, limit, (page-1)*limit)
+	rows, err := dbRaw.DB.Query(queryStr, storeScope, limit, (page-1)*limit)
 
 	if err != nil {
 		return nil, 0, err
 	}
 	defer rows.Close()
 
-	rowCount := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM staff")
+	rowCount := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM staff WHERE "+storeFilter, storeScope)
 
 	var totalCount int
 	if err := rowCount.Scan(&totalCount); err != nil {
@@ -49,7 +74,58 @@ func GetAllStaff(page int, limit int) ([]*model.Staff, int, error) {
 	return staffs, totalCount, nil
 }
 
-func InsertStaff(staff model.Staff) (int64, error) {
+// GetStaffAfter returns up to limit staff ordered by staff_id, starting after the
+// cursor when one is given. The next cursor is empty on the last page and the total
+// is only counted when withCount is set.
+func GetStaffAfter(cursor *pagination.Cursor, limit int, withCount bool, storeScope int) ([]*model.Staff, string, *int, error) {
+	afterId := 0
+	if cursor != nil {
+		afterId = cursor.ID
+	}
+
+	// Fetch one extra row to learn whether there is a next page
+	queryStr := `SELECT ` + queryColumns + ` FROM staff WHERE ` + storeFilter + ` AND staff_id > $2 ORDER BY staff_id LIMIT $3`
+
+	rows, err := dbRaw.DB.Query(queryStr, storeScope, afterId, limit+1)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	defer rows.Close()
+
+	var staffs []*model.Staff
+	for rows.Next() {
+		if f, err := scanStaffRow(rows); err != nil {
+			continue
+		} else {
+			staffs = append(staffs, f)
+		}
+	}
+
+	nextCursor := ""
+	if len(staffs) > limit {
+		staffs = staffs[:limit]
+		nextCursor = pagination.Cursor{ID: staffs[len(staffs)-1].StaffId}.Encode()
+	}
+
+	if !withCount {
+		return staffs, nextCursor, nil, nil
+	}
+
+	var totalCount int
+	if err := dbRaw.DB.QueryRow("SELECT COUNT (*) FROM staff WHERE "+storeFilter, storeScope).Scan(&totalCount); err != nil {
+		return nil, "", nil, err
+	}
+
+	return staffs, nextCursor, &totalCount, nil
+}
+
+// InsertStaff creates a staff member and enqueues their staff.created event in the same
+// transaction. It returns scope.ErrOutOfScope when the staff store is outside storeScope.
+func InsertStaff(staff model.Staff, storeScope int, actor string) (int64, error) {
+	if storeId, _ := strconv.Atoi(staff.StoreId); !scope.Allows(storeScope, storeId) {
+		return 0, scope.ErrOutOfScope
+	}
+
 	query := `
 		INSERT INTO staff (
 			first_name, last_name, address_id, email, store_id, active, username, password, role, picture
@@ -57,8 +133,14 @@ func InsertStaff(staff model.Staff) (int64, error) {
 	    RETURNING staff_id
 	`
 
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	var lastID int64
-	err := dbRaw.DB.QueryRow(query,
+	err = tx.QueryRow(query,
 		staff.FirstName,
 		staff.LastName,
 		staff.AddressId,
@@ -75,14 +157,22 @@ func InsertStaff(staff model.Staff) (int64, error) {
 		return 0, err
 	}
 
+	staff.StaffId = int(lastID)
+	if err := kafka.EnqueueStaffEvent(tx, kafka.NewStaffEvent(kafka.StaffCreated, &staff, actor)); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
 	return lastID, nil
 }
 
 func GetStaff(username string) (*model.Staff, error) {
-	query := `SELECT username, password, role FROM staff WHERE username = $1`
+	query := `SELECT username, password, role, store_id, active FROM staff WHERE username = $1`
 	row := dbRaw.DB.QueryRow(query, username)
 	var user model.Staff
-	if err := row.Scan(&user.Username, &user.Password, &user.Role); err != nil {
+	if err := row.Scan(&user.Username, &user.Password, &user.Role, &user.StoreId, &user.Active); err != nil {
 		return nil, err
 	}
 	return &user, nil
@@ -97,3 +187,240 @@ func IsUsernameExists(username string) (bool, error) {
 	}
 	return count > 0, nil
 }
+
+// GetStaffDetail returns a staff member, or nil when they do not exist within storeScope
+func GetStaffDetail(staffId int, storeScope int) (*model.Staff, error) {
+	queryStr := `SELECT ` + queryColumns + ` FROM staff WHERE ` + storeFilter + ` AND staff_id = $2`
+
+	staff, err := scanStaffRow(dbRaw.DB.QueryRow(queryStr, storeScope, staffId))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+// GetStaffId returns the ID of the staff member with the given username
+func GetStaffId(username string) (int, error) {
+	var staffId int
+	err := dbRaw.DB.QueryRow(`SELECT staff_id FROM staff WHERE username = $1`, username).Scan(&staffId)
+	return staffId, err
+}
+
+// UpdateStaff updates a staff profile and enqueues a staff.updated event with the
+// stored record. It returns sql.ErrNoRows when the staff member does not exist within
+// storeScope and scope.ErrOutOfScope when moving them to a store outside it.
+func UpdateStaff(staffId int, staff model.Staff, storeScope int, actor string) error {
+	if storeId, _ := strconv.Atoi(staff.StoreId); !scope.Allows(storeScope, storeId) {
+		return scope.ErrOutOfScope
+	}
+
+	query := `
+		UPDATE staff SET
+			first_name = $1, last_name = $2, address_id = $3, email = $4,
+			store_id = $5, role = $6, last_update = $7
+		WHERE staff_id = $8 AND ($9 = 0 OR store_id = $9)
+		RETURNING ` + queryColumns
+
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updated, err := scanStaffRow(tx.QueryRow(query,
+		staff.FirstName,
+		staff.LastName,
+		staff.AddressId,
+		staff.Email,
+		staff.StoreId,
+		staff.Role,
+		staff.LastUpdate,
+		staffId,
+		storeScope,
+	))
+	if err != nil {
+		return err
+	}
+
+	return enqueueStaffEvent(tx, kafka.StaffUpdated, updated, actor)
+}
+
+// SetStaffActive deactivates or reactivates a staff member. Deactivating is preferred
+// over deleting because it keeps their rental and payment history.
+func SetStaffActive(staffId int, active bool, storeScope int, actor string) error {
+	query := `UPDATE staff SET active = $1, last_update = $2 WHERE staff_id = $3 AND ($4 = 0 OR store_id = $4) RETURNING ` + queryColumns
+
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	staff, err := scanStaffRow(tx.QueryRow(query, active, time.Now(), staffId, storeScope))
+	if err != nil {
+		return err
+	}
+
+	eventType := kafka.StaffDeactivated
+	if active {
+		eventType = kafka.StaffActivated
+	}
+	return enqueueStaffEvent(tx, eventType, staff, actor)
+}
+
+// DeleteStaff removes a staff member. It returns ErrStaffInUse when rentals, payments
+// or a store still reference them; such staff can only be deactivated.
+func DeleteStaff(staffId int, storeScope int, actor string) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	staff, err := scanStaffRow(tx.QueryRow(`DELETE FROM staff WHERE staff_id = $1 AND ($2 = 0 OR store_id = $2) RETURNING `+deletedColumns,
+		staffId, storeScope))
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			return ErrStaffInUse
+		}
+		return err
+	}
+
+	return enqueueStaffEvent(tx, kafka.StaffDeleted, staff, actor)
+}
+
+// enqueueStaffEvent adds the event to the outbox and commits tx
+func enqueueStaffEvent(tx *sql.Tx, eventType string, staff *model.Staff, actor string) error {
+	if err := kafka.EnqueueStaffEvent(tx, kafka.NewStaffEvent(eventType, staff, actor)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UpdatePassword stores a new password hash for the staff member. The replaced hash
+// moves to the password history, which keeps the newest keepHistory entries.
+func UpdatePassword(username string, hashedPassword string, keepHistory int) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	if err := archivePassword(tx, username, keepHistory, now); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`UPDATE staff SET password = $1, last_update = $2 WHERE username = $3`,
+		hashedPassword, now, username)
+	if err != nil {
+		return err
+	}
+	if err := requireRowAffected(result); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpgradePasswordHash swaps a hash for one of the same password made at a higher cost.
+// It does nothing if the password changed since oldHash was read.
+func UpgradePasswordHash(username string, oldHash string, newHash string) error {
+	_, err := dbRaw.DB.Exec(`UPDATE staff SET password = $1 WHERE username = $2 AND password = $3`,
+		newHash, username, oldHash)
+	return err
+}
+
+// InsertAuthEvent records a security relevant event, such as a lockout, in the event log
+func InsertAuthEvent(message string, context string) error {
+	_, err := dbRaw.DB.Exec(`INSERT INTO event_logs (service, message, context, created_at) VALUES ($1, $2, $3, $4)`,
+		"auth", message, context, time.Now())
+	return err
+}
+
+func requireRowAffected(result sql.Result) error {
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetStaffPicture returns the uploaded picture record, or nil when there is none
+func GetStaffPicture(staffId int) (*model.StaffPicture, error) {
+	query := `
+		SELECT staff_id, picture_key, picture_etag, thumbnail_key, thumbnail_etag, updated_at
+		FROM staff_pictures WHERE staff_id = $1
+	`
+	var p model.StaffPicture
+	err := dbRaw.DB.QueryRow(query, staffId).Scan(&p.StaffId, &p.PictureKey, &p.PictureETag, &p.ThumbnailKey, &p.ThumbnailETag, &p.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// GetLegacyPicture returns the picture stored inline in the staff table, if any
+func GetLegacyPicture(staffId int) ([]byte, error) {
+	var picture []byte
+	err := dbRaw.DB.QueryRow(`SELECT picture FROM staff WHERE staff_id = $1`, staffId).Scan(&picture)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return picture, err
+}
+
+// SaveStaffPicture records an uploaded picture and clears the legacy inline picture
+func SaveStaffPicture(p model.StaffPicture) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO staff_pictures (staff_id, picture_key, picture_etag, thumbnail_key, thumbnail_etag, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (staff_id) DO UPDATE SET
+			picture_key = EXCLUDED.picture_key, picture_etag = EXCLUDED.picture_etag,
+			thumbnail_key = EXCLUDED.thumbnail_key, thumbnail_etag = EXCLUDED.thumbnail_etag,
+			updated_at = EXCLUDED.updated_at
+	`
+	if _, err := tx.Exec(query, p.StaffId, p.PictureKey, p.PictureETag, p.ThumbnailKey, p.ThumbnailETag, p.UpdatedAt); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE staff SET picture = NULL WHERE staff_id = $1`, p.StaffId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// DeleteStaffPicture removes both the uploaded and the legacy picture
+func DeleteStaffPicture(staffId int) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM staff_pictures WHERE staff_id = $1`, staffId); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE staff SET picture = NULL WHERE staff_id = $1`, staffId); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -1,12 +1,17 @@
 package repository_test
 
 import (
+	staffModel "film-rental/internal/staff/model"
 	"film-rental/internal/staff/repository"
 	model "film-rental/internal/token/model"
 	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/pagination"
+	"film-rental/pkg/scope"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 )
 
 // TestIsUsernameExists_Mock tests the username uniqueness check functionality
@@ -90,3 +95,126 @@ func TestRefreshTokenFlow(t *testing.T) {
 		t.Fatalf("Expected expires_in to be 900, got %d", tokenResp.ExpiresIn)
 	}
 }
+
+// TestGetStaffAfter_Mock tests keyset pagination over staff
+func TestGetStaffAfter_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	columns := []string{"staff_id", "first_name", "last_name", "address_id", "email", "store_id", "active", "username", "role", "last_update", "has_picture"}
+	now := time.Now()
+
+	// First page: one extra row means there is a next page
+	mock.ExpectQuery(`SELECT (.+) FROM staff WHERE \(\$1 = 0 OR store_id = \$1\) AND staff_id > \$2 ORDER BY staff_id LIMIT \$3`).
+		WithArgs(1, 0, 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(1, "Mike", "Hillyer", 3, "mike@example.org", "1", true, "mike", "admin", now, true).
+			AddRow(2, "Jon", "Stephens", 4, "jon@example.org", "2", true, "jon", "user", now, false).
+			AddRow(3, "Ann", "Lee", 5, "ann@example.org", "1", true, "ann", "user", now, false))
+
+	staffs, next, count, err := repository.GetStaffAfter(nil, 2, false, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(staffs) != 2 || next == "" || count != nil {
+		t.Fatalf("expected 2 staff, a next cursor and no count, got %d %q %v", len(staffs), next, count)
+	}
+
+	cursor, err := pagination.DecodeCursor(next)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Last page: no extra row, so no next cursor
+	mock.ExpectQuery(`SELECT (.+) FROM staff WHERE \(\$1 = 0 OR store_id = \$1\) AND staff_id > \$2 ORDER BY staff_id LIMIT \$3`).
+		WithArgs(1, 2, 3).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(3, "Ann", "Lee", 5, "ann@example.org", "1", true, "ann", "user", now, false))
+	mock.ExpectQuery(`SELECT COUNT \(\*\) FROM staff`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+
+	staffs, next, count, err = repository.GetStaffAfter(cursor, 2, true, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(staffs) != 1 || next != "" || count == nil || *count != 3 {
+		t.Fatalf("expected the last staff and a count of 3, got %d %q %v", len(staffs), next, count)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %s", err)
+	}
+}
+
+// TestUpdateStaff_OutOfScope tests that staff cannot be moved to a store outside the scope
+func TestUpdateStaff_OutOfScope(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	err = repository.UpdateStaff(1, staffModel.Staff{StoreId: "2"}, 1, "mike")
+	if err != scope.ErrOutOfScope {
+		t.Fatalf("expected ErrOutOfScope, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestDeleteStaff_InUse tests that staff with history cannot be hard deleted
+func TestDeleteStaff_InUse(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM staff WHERE staff_id = \$1`).
+		WithArgs(1, 0).
+		WillReturnError(&pq.Error{Code: "23503"})
+	mock.ExpectRollback()
+
+	if err := repository.DeleteStaff(1, 0, "mike"); err != repository.ErrStaffInUse {
+		t.Fatalf("expected ErrStaffInUse, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// TestInsertAuthEvent tests that auth events land in the shared event log
+func TestInsertAuthEvent(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+
+	dbRaw.DB = mockDB
+
+	mock.ExpectExec(`INSERT INTO event_logs \(service, message, context, created_at\)`).
+		WithArgs("auth", "account_locked", `{"username":"mike"}`, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if err := repository.InsertAuthEvent("account_locked", `{"username":"mike"}`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
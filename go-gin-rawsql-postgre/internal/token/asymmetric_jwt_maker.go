@@ -0,0 +1,426 @@
+package token
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported asymmetric signing algorithms
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+const rsaKeyBits = 2048
+
+const (
+	// JWKSMaxAge is how long clients may cache the key set
+	JWKSMaxAge = 5 * time.Minute
+	// keySyncInterval is how often StartKeyRotation reloads the shared keys
+	keySyncInterval = time.Minute
+	// KeyPublishAhead is how long a rotated key is published before it signs. By then
+	// every instance has loaded it and every cached key set has expired, so tokens it
+	// signs verify everywhere.
+	KeyPublishAhead = JWKSMaxAge + 2*keySyncInterval
+)
+
+var (
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	ErrNoSigningKey         = errors.New("no signing key is active")
+)
+
+type signingKey struct {
+	kid         string
+	private     crypto.Signer
+	public      crypto.PublicKey
+	activatesAt time.Time
+}
+
+// StoredKey is a signing key as kept in a KeyStore
+type StoredKey struct {
+	Private     crypto.Signer
+	ActivatesAt time.Time
+}
+
+// KeyStore shares the signing keys of an algorithm between instances
+type KeyStore interface {
+	// LoadKeys returns the stored keys of algorithm
+	LoadKeys(algorithm string) ([]StoredKey, error)
+	// AddKey stores key unless the newest stored key no longer activates at latest (zero
+	// when there is none), i.e. another instance rotated first. It reports whether key
+	// was stored.
+	AddKey(algorithm string, key StoredKey, latest time.Time) (bool, error)
+	// PruneKeys deletes the keys whose successor activated before the given time
+	PruneKeys(algorithm string, before time.Time) error
+}
+
+// AsymmetricJWTMaker signs tokens with RS256 or EdDSA keys identified by a kid header.
+// The newest key that has activated signs; every key is published and verifies from the
+// moment it is added, and keeps verifying for the retention period after its successor
+// activates, so rotation never invalidates tokens that are still alive.
+type AsymmetricJWTMaker struct {
+	algorithm string
+	method    jwt.SigningMethod
+	retention time.Duration
+
+	mu   sync.RWMutex
+	keys map[string]*signingKey
+}
+
+// NewAsymmetricJWTMaker creates a maker for the given algorithm without keys; add them
+// with AddKey or RotateIfDue before issuing tokens. retention should be at least the
+// lifetime of the longest token issued.
+func NewAsymmetricJWTMaker(algorithm string, retention time.Duration) (*AsymmetricJWTMaker, error) {
+	var method jwt.SigningMethod
+	switch algorithm {
+	case AlgorithmRS256:
+		method = jwt.SigningMethodRS256
+	case AlgorithmEdDSA:
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+	}
+
+	return &AsymmetricJWTMaker{
+		algorithm: algorithm,
+		method:    method,
+		retention: retention,
+		keys:      make(map[string]*signingKey),
+	}, nil
+}
+
+// GenerateKey creates a random private key for algorithm
+func GenerateKey(algorithm string) (crypto.Signer, error) {
+	switch algorithm {
+	case AlgorithmRS256:
+		return rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	case AlgorithmEdDSA:
+		_, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		return privateKey, err
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, algorithm)
+}
+
+// AddKey adds a private key, e.g. loaded from a PEM file, that signs from activatesAt
+// on. It returns the kid derived from the public key.
+func (maker *AsymmetricJWTMaker) AddKey(privateKey crypto.Signer, activatesAt time.Time) (string, error) {
+	key, err := maker.newSigningKey(privateKey, activatesAt)
+	if err != nil {
+		return "", err
+	}
+
+	maker.mu.Lock()
+	defer maker.mu.Unlock()
+
+	maker.keys[key.kid] = key
+	maker.pruneLocked(time.Now())
+	return key.kid, nil
+}
+
+func (maker *AsymmetricJWTMaker) newSigningKey(privateKey crypto.Signer, activatesAt time.Time) (*signingKey, error) {
+	switch privateKey.(type) {
+	case *rsa.PrivateKey:
+		if maker.algorithm != AlgorithmRS256 {
+			return nil, fmt.Errorf("%w: RSA key for %s", ErrUnsupportedAlgorithm, maker.algorithm)
+		}
+	case ed25519.PrivateKey:
+		if maker.algorithm != AlgorithmEdDSA {
+			return nil, fmt.Errorf("%w: Ed25519 key for %s", ErrUnsupportedAlgorithm, maker.algorithm)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, privateKey)
+	}
+
+	kid, err := KeyID(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+	return &signingKey{kid: kid, private: privateKey, public: privateKey.Public(), activatesAt: activatesAt}, nil
+}
+
+// SyncKeys replaces the keys with those in store
+func (maker *AsymmetricJWTMaker) SyncKeys(store KeyStore) error {
+	stored, err := store.LoadKeys(maker.algorithm)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]*signingKey, len(stored))
+	for _, s := range stored {
+		key, err := maker.newSigningKey(s.Private, s.ActivatesAt)
+		if err != nil {
+			return err
+		}
+		keys[key.kid] = key
+	}
+
+	maker.mu.Lock()
+	defer maker.mu.Unlock()
+
+	maker.keys = keys
+	maker.pruneLocked(time.Now())
+	return nil
+}
+
+// RotateIfDue syncs the keys with store and, when the newest key activated at least
+// interval ago minus KeyPublishAhead, stores a new key that activates KeyPublishAhead
+// from now at the earliest. The first key activates at once since no key set can be
+// cached yet. Instances sharing store rotate once between them. It returns the kid of
+// the key this call stored, or "" when it stored none.
+func (maker *AsymmetricJWTMaker) RotateIfDue(store KeyStore, interval time.Duration, now time.Time) (string, error) {
+	if err := maker.SyncKeys(store); err != nil {
+		return "", err
+	}
+
+	latest := maker.latestActivation()
+	activatesAt := now
+	if !latest.IsZero() {
+		if now.Before(latest.Add(interval - KeyPublishAhead)) {
+			return "", nil
+		}
+		activatesAt = latest.Add(interval)
+		if earliest := now.Add(KeyPublishAhead); activatesAt.Before(earliest) {
+			activatesAt = earliest
+		}
+	}
+
+	privateKey, err := GenerateKey(maker.algorithm)
+	if err != nil {
+		return "", err
+	}
+	kid, err := KeyID(privateKey.Public())
+	if err != nil {
+		return "", err
+	}
+
+	stored, err := store.AddKey(maker.algorithm, StoredKey{Private: privateKey, ActivatesAt: activatesAt}, latest)
+	if err != nil {
+		return "", err
+	}
+	if !stored {
+		// Another instance rotated first; pick up its key
+		return "", maker.SyncKeys(store)
+	}
+	if err := store.PruneKeys(maker.algorithm, now.Add(-maker.retention)); err != nil {
+		return "", err
+	}
+	return kid, maker.SyncKeys(store)
+}
+
+// StartKeyRotation reloads the keys from store every minute and rotates them every
+// interval, which must exceed KeyPublishAhead, until ctx is cancelled
+func (maker *AsymmetricJWTMaker) StartKeyRotation(ctx context.Context, store KeyStore, interval time.Duration) {
+	ticker := time.NewTicker(keySyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			kid, err := maker.RotateIfDue(store, interval, time.Now())
+			if err != nil {
+				log.Printf("❌ Failed to rotate signing key: %v", err)
+				continue
+			}
+			if kid != "" {
+				log.Printf("🔑 Published signing key %s, it signs from %s", kid, maker.latestActivation().Format(time.RFC3339))
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// latestActivation returns when the newest key activates, zero without keys
+func (maker *AsymmetricJWTMaker) latestActivation() time.Time {
+	maker.mu.RLock()
+	defer maker.mu.RUnlock()
+
+	var latest time.Time
+	for _, key := range maker.keys {
+		if key.activatesAt.After(latest) {
+			latest = key.activatesAt
+		}
+	}
+	return latest
+}
+
+// sortedKeysLocked returns the keys newest first
+func (maker *AsymmetricJWTMaker) sortedKeysLocked() []*signingKey {
+	keys := make([]*signingKey, 0, len(maker.keys))
+	for _, key := range maker.keys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].activatesAt.Equal(keys[j].activatesAt) {
+			return keys[i].activatesAt.After(keys[j].activatesAt)
+		}
+		return keys[i].kid < keys[j].kid
+	})
+	return keys
+}
+
+// activeKeyLocked returns the newest key that has activated
+func (maker *AsymmetricJWTMaker) activeKeyLocked(now time.Time) *signingKey {
+	for _, key := range maker.sortedKeysLocked() {
+		if !key.activatesAt.After(now) {
+			return key
+		}
+	}
+	return nil
+}
+
+// pruneLocked drops keys whose successor activated longer than the retention period
+// ago, so they can no longer have live tokens
+func (maker *AsymmetricJWTMaker) pruneLocked(now time.Time) {
+	keys := maker.sortedKeysLocked()
+	for i := 1; i < len(keys); i++ {
+		if now.Sub(keys[i-1].activatesAt) > maker.retention {
+			delete(maker.keys, keys[i].kid)
+		}
+	}
+}
+
+func (maker *AsymmetricJWTMaker) CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, error) {
+	payload, err := NewPayload(username, role, duration, tokenType)
+	if err != nil {
+		return "", err
+	}
+	return maker.CreateTokenFromPayload(payload)
+}
+
+func (maker *AsymmetricJWTMaker) CreateTokenFromPayload(payload *Payload) (string, error) {
+	maker.mu.RLock()
+	key := maker.activeKeyLocked(time.Now())
+	maker.mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	jwtToken := jwt.NewWithClaims(maker.method, payload)
+	jwtToken.Header["kid"] = key.kid
+	return jwtToken.SignedString(key.private)
+}
+
+// VerifyToken checks the token signature against the key named by its kid header
+func (maker *AsymmetricJWTMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	keyFunc := func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() != maker.method.Alg() {
+			return nil, ErrInvalidToken
+		}
+		kid, ok := token.Header["kid"].(string)
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+
+		maker.mu.RLock()
+		key, ok := maker.keys[kid]
+		maker.mu.RUnlock()
+		if !ok {
+			return nil, ErrInvalidToken
+		}
+		return key.public, nil
+	}
+
+	jwtToken, err := jwt.ParseWithClaims(token, &Payload{}, keyFunc)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	payload, ok := jwtToken.Claims.(*Payload)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid(tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// JWKS returns the public keys that can currently verify tokens, including keys that
+// will sign soon, newest first
+func (maker *AsymmetricJWTMaker) JWKS() JSONWebKeySet {
+	maker.mu.RLock()
+	defer maker.mu.RUnlock()
+
+	keys := maker.sortedKeysLocked()
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0, len(keys))}
+	for _, key := range keys {
+		jwk := JSONWebKey{Kid: key.kid, Use: "sig", Alg: maker.algorithm}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(public)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// KeyID derives a stable kid from the public key so every instance loading the same
+// key file advertises the same kid
+func KeyID(public crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
+
+// LoadPrivateKey reads a PEM encoded PKCS#8 (RSA or Ed25519) or PKCS#1 (RSA) private key
+func LoadPrivateKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in %s", path)
+	}
+	return ParsePrivateKey(block.Bytes)
+}
+
+// ParsePrivateKey parses a DER encoded PKCS#8 (RSA or Ed25519) or PKCS#1 (RSA) private key
+func ParsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedAlgorithm, key)
+	}
+	return signer, nil
+}
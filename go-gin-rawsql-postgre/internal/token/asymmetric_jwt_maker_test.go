@@ -0,0 +1,188 @@
+package token
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryKeyStore is a KeyStore shared by the makers of a test, standing in for the
+// database shared by instances
+type memoryKeyStore struct {
+	mu   sync.Mutex
+	keys []StoredKey
+}
+
+func (s *memoryKeyStore) LoadKeys(algorithm string) ([]StoredKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StoredKey(nil), s.keys...), nil
+}
+
+func (s *memoryKeyStore) AddKey(algorithm string, key StoredKey, latest time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var current time.Time
+	for _, k := range s.keys {
+		if k.ActivatesAt.After(current) {
+			current = k.ActivatesAt
+		}
+	}
+	if !current.Equal(latest) {
+		return false, nil
+	}
+	s.keys = append(s.keys, key)
+	return true, nil
+}
+
+func (s *memoryKeyStore) PruneKeys(algorithm string, before time.Time) error {
+	return nil
+}
+
+// newKeyedMaker returns a maker signing with a freshly generated key
+func newKeyedMaker(t *testing.T, algorithm string, retention time.Duration) *AsymmetricJWTMaker {
+	maker, err := NewAsymmetricJWTMaker(algorithm, retention)
+	require.NoError(t, err)
+	privateKey, err := GenerateKey(algorithm)
+	require.NoError(t, err)
+	_, err = maker.AddKey(privateKey, time.Time{})
+	require.NoError(t, err)
+	return maker
+}
+
+func tokenKid(t *testing.T, token string) string {
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &Payload{})
+	require.NoError(t, err)
+	return parsed.Header["kid"].(string)
+}
+
+func TestNewAsymmetricJWTMaker_UnsupportedAlgorithm(t *testing.T) {
+	maker, err := NewAsymmetricJWTMaker("HS512", time.Hour)
+	require.ErrorIs(t, err, ErrUnsupportedAlgorithm)
+	require.Nil(t, maker)
+}
+
+func TestAsymmetricJWTMaker_NoSigningKey(t *testing.T) {
+	maker, err := NewAsymmetricJWTMaker(AlgorithmEdDSA, time.Hour)
+	require.NoError(t, err)
+
+	_, err = maker.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+	require.Equal(t, ErrNoSigningKey, err)
+	require.Empty(t, maker.JWKS().Keys)
+}
+
+func TestAsymmetricJWTMaker_PendingKeyIsPublishedBeforeSigning(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmRS256, AlgorithmEdDSA} {
+		t.Run(algorithm, func(t *testing.T) {
+			maker, err := NewAsymmetricJWTMaker(algorithm, time.Hour)
+			require.NoError(t, err)
+
+			current, err := GenerateKey(algorithm)
+			require.NoError(t, err)
+			currentKid, err := maker.AddKey(current, time.Now().Add(-time.Hour))
+			require.NoError(t, err)
+
+			next, err := GenerateKey(algorithm)
+			require.NoError(t, err)
+			nextKid, err := maker.AddKey(next, time.Now().Add(KeyPublishAhead))
+			require.NoError(t, err)
+
+			// The next key is published but the current one still signs
+			jwks := maker.JWKS()
+			require.Len(t, jwks.Keys, 2)
+			require.Equal(t, nextKid, jwks.Keys[0].Kid)
+			require.Equal(t, algorithm, jwks.Keys[0].Alg)
+
+			token, err := maker.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+			require.NoError(t, err)
+			require.Equal(t, currentKid, tokenKid(t, token))
+
+			// Once a newer key has activated it signs, and the old token still verifies
+			newest, err := GenerateKey(algorithm)
+			require.NoError(t, err)
+			newestKid, err := maker.AddKey(newest, time.Now().Add(-time.Minute))
+			require.NoError(t, err)
+
+			newToken, err := maker.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+			require.NoError(t, err)
+			require.Equal(t, newestKid, tokenKid(t, newToken))
+
+			_, err = maker.VerifyToken(token, TokenTypeAccessToken)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAsymmetricJWTMaker_RotateIfDueSharesKeys(t *testing.T) {
+	store := &memoryKeyStore{}
+	interval := 24 * time.Hour
+	now := time.Now()
+
+	first, err := NewAsymmetricJWTMaker(AlgorithmEdDSA, 7*24*time.Hour)
+	require.NoError(t, err)
+	second, err := NewAsymmetricJWTMaker(AlgorithmEdDSA, 7*24*time.Hour)
+	require.NoError(t, err)
+
+	// The first instance to start creates a key that signs at once
+	firstKid, err := first.RotateIfDue(store, interval, now)
+	require.NoError(t, err)
+	require.NotEmpty(t, firstKid)
+	kid, err := second.RotateIfDue(store, interval, now)
+	require.NoError(t, err)
+	require.Empty(t, kid)
+
+	// Tokens of one instance verify on the other
+	token, err := first.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+	require.NoError(t, err)
+	_, err = second.VerifyToken(token, TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.Equal(t, first.JWKS(), second.JWKS())
+
+	// Not due yet
+	kid, err = first.RotateIfDue(store, interval, now.Add(time.Hour))
+	require.NoError(t, err)
+	require.Empty(t, kid)
+
+	// Due: both instances try, one key is stored and it activates a publish period later
+	due := now.Add(interval - KeyPublishAhead)
+	nextKid, err := second.RotateIfDue(store, interval, due)
+	require.NoError(t, err)
+	require.NotEmpty(t, nextKid)
+	kid, err = first.RotateIfDue(store, interval, due)
+	require.NoError(t, err)
+	require.Empty(t, kid)
+
+	require.Len(t, store.keys, 2)
+	require.False(t, store.keys[1].ActivatesAt.Before(due.Add(KeyPublishAhead)))
+	require.Equal(t, nextKid, first.JWKS().Keys[0].Kid)
+
+	// The current key keeps signing until then
+	token, err = second.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+	require.NoError(t, err)
+	require.Equal(t, firstKid, tokenKid(t, token))
+}
+
+func TestAsymmetricJWTMaker_RetiredKeyExpires(t *testing.T) {
+	maker, err := NewAsymmetricJWTMaker(AlgorithmEdDSA, 0)
+	require.NoError(t, err)
+
+	first, err := GenerateKey(AlgorithmEdDSA)
+	require.NoError(t, err)
+	_, err = maker.AddKey(first, time.Now().Add(-2*time.Second))
+	require.NoError(t, err)
+
+	token, err := maker.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	second, err := GenerateKey(AlgorithmEdDSA)
+	require.NoError(t, err)
+	_, err = maker.AddKey(second, time.Now().Add(-time.Second))
+	require.NoError(t, err)
+
+	// The first key was succeeded past the retention period and pruned
+	_, err = maker.VerifyToken(token, TokenTypeAccessToken)
+	require.Equal(t, ErrInvalidToken, err)
+}
@@ -0,0 +1,28 @@
+package handler
+
+import (
+	"film-rental/internal/token"
+	"film-rental/pkg/response"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS serves the public keys downstream services use to verify our tokens. The
+// key set is returned as-is rather than in the response envelope so standard JWKS
+// clients can read it.
+func GetJWKS(tokenMaker token.Maker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provider, ok := tokenMaker.(token.KeySetProvider)
+		if !ok {
+			response.WriteError(c, http.StatusNotFound, "Tokens are not signed with public keys", nil)
+			return
+		}
+
+		// Rotated keys are published token.KeyPublishAhead before they sign, which
+		// covers this cache period
+		c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(token.JWKSMaxAge.Seconds())))
+		c.JSON(http.StatusOK, provider.JWKS())
+	}
+}
@@ -26,10 +26,13 @@ func (maker *JWTMaker) CreateToken(username string, role string, duration time.D
 	if err != nil {
 		return "", err
 	}
+	return maker.CreateTokenFromPayload(payload)
+}
 
+// CreateTokenFromPayload signs a payload built by the caller, e.g. with a store scope
+func (maker *JWTMaker) CreateTokenFromPayload(payload *Payload) (string, error) {
 	jwtToken := jwt.NewWithClaims(jwt.SigningMethodHS256, payload)
-	token, err := jwtToken.SignedString([]byte(maker.secretKey))
-	return token, err
+	return jwtToken.SignedString([]byte(maker.secretKey))
 }
 
 // VerifyToken checks if the token is valid or not
@@ -32,21 +32,75 @@ func TestCreateToken(t *testing.T) {
 	require.Len(t, strings.Split(token, "."), 3)
 }
 
-func TestVerifyToken_InvalidOrExpired(t *testing.T) {
-	maker, err := NewJWTMaker("12345678901234567890123456789012")
+// newTestMakers returns one maker per supported signing algorithm
+func newTestMakers(t *testing.T) map[string]Maker {
+	hmacMaker, err := NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+	rsaMaker := newKeyedMaker(t, AlgorithmRS256, time.Hour)
+	edMaker := newKeyedMaker(t, AlgorithmEdDSA, time.Hour)
+	pasetoMaker, err := NewPasetoMaker("12345678901234567890123456789012")
 	require.NoError(t, err)
 
-	// Invalid token
-	payload, err := maker.VerifyToken("invalid.token.here", TokenTypeAccessToken)
-	require.Error(t, err)
-	require.Nil(t, payload)
-	require.Equal(t, ErrInvalidToken, err)
+	return map[string]Maker{
+		"HS256":        hmacMaker,
+		AlgorithmRS256: rsaMaker,
+		AlgorithmEdDSA: edMaker,
+		"PASETO":       pasetoMaker,
+	}
+}
 
-	// Expired token
-	token, err := maker.CreateToken("testuser", "admin", -time.Minute, TokenTypeAccessToken)
-	require.NoError(t, err)
-	payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
-	require.Error(t, err)
-	require.Nil(t, payload)
-	require.Equal(t, ErrExpiredToken, err)
+func TestVerifyToken(t *testing.T) {
+	for name, maker := range newTestMakers(t) {
+		t.Run(name, func(t *testing.T) {
+			token, err := maker.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+			require.NoError(t, err)
+
+			payload, err := maker.VerifyToken(token, TokenTypeAccessToken)
+			require.NoError(t, err)
+			require.Equal(t, "testuser", payload.Username)
+			require.Equal(t, "admin", payload.Role)
+
+			// Wrong token type
+			payload, err = maker.VerifyToken(token, TokenTypeRefreshToken)
+			require.Equal(t, ErrInvalidToken, err)
+			require.Nil(t, payload)
+		})
+	}
+}
+
+func TestVerifyToken_InvalidOrExpired(t *testing.T) {
+	for name, maker := range newTestMakers(t) {
+		t.Run(name, func(t *testing.T) {
+			// Invalid token
+			payload, err := maker.VerifyToken("invalid.token.here", TokenTypeAccessToken)
+			require.Error(t, err)
+			require.Nil(t, payload)
+			require.Equal(t, ErrInvalidToken, err)
+
+			// Expired token
+			token, err := maker.CreateToken("testuser", "admin", -time.Minute, TokenTypeAccessToken)
+			require.NoError(t, err)
+			payload, err = maker.VerifyToken(token, TokenTypeAccessToken)
+			require.Error(t, err)
+			require.Nil(t, payload)
+			require.Equal(t, ErrExpiredToken, err)
+		})
+	}
+}
+
+func TestVerifyToken_ForeignSigner(t *testing.T) {
+	makers := newTestMakers(t)
+	for signerName, signer := range makers {
+		token, err := signer.CreateToken("testuser", "admin", time.Minute, TokenTypeAccessToken)
+		require.NoError(t, err)
+
+		for verifierName, verifier := range makers {
+			if verifierName == signerName {
+				continue
+			}
+			payload, err := verifier.VerifyToken(token, TokenTypeAccessToken)
+			require.Equal(t, ErrInvalidToken, err, "%s token accepted by %s maker", signerName, verifierName)
+			require.Nil(t, payload)
+		}
+	}
 }
@@ -0,0 +1,38 @@
+package token
+
+import "time"
+
+// Maker is the interface for creating and verifying tokens
+type Maker interface {
+	// CreateToken creates a new token for a specific username, role and duration
+	CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, error)
+
+	// CreateTokenFromPayload signs a payload built by the caller
+	CreateTokenFromPayload(payload *Payload) (string, error)
+
+	// VerifyToken checks if the token is valid or not
+	VerifyToken(token string, tokenType TokenType) (*Payload, error)
+}
+
+// KeySetProvider is implemented by makers that sign with asymmetric keys and can
+// publish their public keys
+type KeySetProvider interface {
+	JWKS() JSONWebKeySet
+}
+
+// JSONWebKey is a public key in JWK format (RFC 7517)
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet is the document served at /.well-known/jwks.json
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
@@ -1,5 +1,7 @@
 package model
 
+import "log"
+
 // Role constants
 const (
 	RoleAdmin = "admin"
@@ -20,32 +22,114 @@ const (
 	PermissionStaffUpdate = "staff:update"
 	PermissionStaffDelete = "staff:delete"
 
+	// Rental permissions
+	PermissionRentalRead   = "rental:read"
+	PermissionRentalCreate = "rental:create"
+	PermissionRentalUpdate = "rental:update"
+
+	// Customer permissions
+	PermissionCustomerRead   = "customer:read"
+	PermissionCustomerCreate = "customer:create"
+	PermissionCustomerUpdate = "customer:update"
+	PermissionCustomerDelete = "customer:delete"
+
+	// Inventory permissions
+	PermissionInventoryRead   = "inventory:read"
+	PermissionInventoryCreate = "inventory:create"
+	PermissionInventoryDelete = "inventory:delete"
+
+	// Payment permissions
+	PermissionPaymentRead   = "payment:read"
+	PermissionPaymentCreate = "payment:create"
+	PermissionPaymentRefund = "payment:refund"
+
+	// Report permissions
+	PermissionReportRead = "report:read"
+
 	// User management permissions
 	PermissionUserRead   = "user:read"
 	PermissionUserCreate = "user:create"
 	PermissionUserUpdate = "user:update"
 	PermissionUserDelete = "user:delete"
+	PermissionUserRevoke = "user:revoke"
+	PermissionUserUnlock = "user:unlock"
+
+	// Store scope permissions
+	PermissionStoreAll = "store:all"
+
+	// Role management permissions
+	PermissionRoleRead   = "role:read"
+	PermissionRoleCreate = "role:create"
+	PermissionRoleUpdate = "role:update"
+
+	// Event stream permissions
+	PermissionEventRead   = "event:read"
+	PermissionEventReplay = "event:replay"
 )
 
-// RolePermissions maps roles to their allowed permissions
+// RolePermissions maps the built-in roles to their default permissions. It is seeded
+// into the database on startup; after that roles and grants are managed at runtime.
 var RolePermissions = map[string][]string{
 	RoleAdmin: {
 		// Admin has all permissions
 		PermissionFilmRead, PermissionFilmCreate, PermissionFilmUpdate, PermissionFilmDelete,
 		PermissionStaffRead, PermissionStaffCreate, PermissionStaffUpdate, PermissionStaffDelete,
-		PermissionUserRead, PermissionUserCreate, PermissionUserUpdate, PermissionUserDelete,
+		PermissionUserRead, PermissionUserCreate, PermissionUserUpdate, PermissionUserDelete, PermissionUserRevoke, PermissionUserUnlock,
+		PermissionRentalRead, PermissionRentalCreate, PermissionRentalUpdate,
+		PermissionCustomerRead, PermissionCustomerCreate, PermissionCustomerUpdate, PermissionCustomerDelete,
+		PermissionInventoryRead, PermissionInventoryCreate, PermissionInventoryDelete,
+		PermissionPaymentRead, PermissionPaymentCreate, PermissionPaymentRefund,
+		PermissionReportRead,
+		PermissionRoleRead, PermissionRoleCreate, PermissionRoleUpdate,
+		PermissionEventRead, PermissionEventReplay,
+		PermissionStoreAll,
 	},
 	RoleUser: {
 		// User has limited permissions
 		PermissionFilmRead,
 		PermissionStaffRead,
 		PermissionUserRead,
+		PermissionRentalRead, PermissionRentalCreate, PermissionRentalUpdate,
+		PermissionCustomerRead, PermissionCustomerCreate, PermissionCustomerUpdate,
+		PermissionInventoryRead,
+		PermissionPaymentRead, PermissionPaymentCreate,
 	},
 }
 
+// PermissionSource resolves the permissions granted to a role
+type PermissionSource interface {
+	// RolePermissions returns the role's permissions and whether the role exists
+	RolePermissions(role string) ([]string, bool, error)
+}
+
+type staticPermissionSource map[string][]string
+
+func (s staticPermissionSource) RolePermissions(role string) ([]string, bool, error) {
+	permissions, exists := s[role]
+	return permissions, exists, nil
+}
+
+var permissionSource PermissionSource = staticPermissionSource(RolePermissions)
+
+// SetPermissionSource replaces the built-in RolePermissions map as the source of role
+// grants, e.g. with the database-backed cache
+func SetPermissionSource(source PermissionSource) {
+	permissionSource = source
+}
+
+func lookupRole(role string) ([]string, bool) {
+	permissions, exists, err := permissionSource.RolePermissions(role)
+	if err != nil {
+		// Fail closed: a role we cannot look up grants nothing
+		log.Printf("❌ Failed to look up permissions for role %q: %v", role, err)
+		return nil, false
+	}
+	return permissions, exists
+}
+
 // HasPermission checks if a role has a specific permission
 func HasPermission(role, permission string) bool {
-	permissions, exists := RolePermissions[role]
+	permissions, exists := lookupRole(role)
 	if !exists {
 		return false
 	}
@@ -60,7 +144,7 @@ func HasPermission(role, permission string) bool {
 
 // GetRolePermissions returns all permissions for a given role
 func GetRolePermissions(role string) []string {
-	permissions, exists := RolePermissions[role]
+	permissions, exists := lookupRole(role)
 	if !exists {
 		return []string{}
 	}
@@ -69,6 +153,34 @@ func GetRolePermissions(role string) []string {
 
 // IsValidRole checks if a role is valid
 func IsValidRole(role string) bool {
-	_, exists := RolePermissions[role]
+	_, exists := lookupRole(role)
 	return exists
 }
+
+// CanAssignRole reports whether a user with callerRole may give someone role: always
+// with role:update, otherwise only when role grants nothing callerRole lacks, so
+// assigning a role can never escalate privileges
+func CanAssignRole(callerRole, role string) bool {
+	callerPermissions, exists := lookupRole(callerRole)
+	if !exists {
+		return false
+	}
+	held := make(map[string]bool, len(callerPermissions))
+	for _, p := range callerPermissions {
+		held[p] = true
+	}
+	if held[PermissionRoleUpdate] {
+		return true
+	}
+
+	granted, exists := lookupRole(role)
+	if !exists {
+		return false
+	}
+	for _, p := range granted {
+		if !held[p] {
+			return false
+		}
+	}
+	return true
+}
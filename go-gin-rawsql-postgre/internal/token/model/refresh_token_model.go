@@ -0,0 +1,20 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the server-side record of an issued refresh token, keyed by the
+// token payload ID. Every rotation issues a new token in the same family, so a replayed
+// token can revoke everything that descended from the same login.
+type RefreshToken struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey"`
+	FamilyID  uuid.UUID `gorm:"type:uuid;index;not null"`
+	Username  string    `gorm:"size:100;index;not null"`
+	IssuedAt  time.Time `gorm:"not null"`
+	ExpiresAt time.Time `gorm:"not null"`
+	UsedAt    *time.Time
+	RevokedAt *time.Time
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// SigningKey is a JWT signing key shared by every instance. The private key is PKCS#8
+// DER sealed with the server's secret encryption key.
+type SigningKey struct {
+	Kid         string    `gorm:"size:32;primaryKey"`
+	Algorithm   string    `gorm:"size:10;index;not null"`
+	PrivateKey  []byte    `gorm:"not null"`
+	ActivatesAt time.Time `gorm:"not null"`
+	CreatedAt   time.Time `gorm:"not null"`
+}
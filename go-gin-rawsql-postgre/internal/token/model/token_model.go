@@ -18,3 +18,38 @@ type TokenResponse struct {
 	TokenType    string `json:"token_type"`
 	ExpiresIn    int    `json:"expires_in"`
 }
+
+// RevokeTokenRequest is used by admins to revoke a single access token
+type RevokeTokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// MFAChallengeResponse is returned by login when a second factor is needed. The MFA
+// token authenticates the verification, or the enrollment when the role requires MFA
+// and the user has none yet.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	EnrollmentRequired bool   `json:"mfa_enrollment_required"`
+	MFAToken           string `json:"mfa_token"`
+	ExpiresIn          int    `json:"expires_in"`
+}
+
+// MFACodeRequest carries either a TOTP code or a single-use recovery code
+type MFACodeRequest struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFAEnrollmentResponse carries the new secret; the provisioning URI is meant to be
+// shown as a QR code
+type MFAEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAConfirmResponse returns the recovery codes, which are shown only once. Tokens is
+// set when the confirmation completes a login.
+type MFAConfirmResponse struct {
+	RecoveryCodes []string       `json:"recovery_codes"`
+	Tokens        *TokenResponse `json:"tokens,omitempty"`
+}
@@ -0,0 +1,105 @@
+package token
+
+import (
+	"fmt"
+	"time"
+
+	"aidanwoods.dev/go-paseto"
+	"github.com/google/uuid"
+)
+
+// PasetoMaker creates PASETO v4.local tokens. The version fixes the algorithms, so
+// there is no header for an attacker to downgrade as with JWT's alg.
+type PasetoMaker struct {
+	symmetricKey paseto.V4SymmetricKey
+}
+
+// NewPasetoMaker creates a PasetoMaker from a key of exactly 32 characters
+func NewPasetoMaker(symmetricKey string) (*PasetoMaker, error) {
+	key, err := paseto.V4SymmetricKeyFromBytes([]byte(symmetricKey))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key size: must be exactly %d characters", minSecretKeySize)
+	}
+	return &PasetoMaker{symmetricKey: key}, nil
+}
+
+func (maker *PasetoMaker) CreateToken(username string, role string, duration time.Duration, tokenType TokenType) (string, error) {
+	payload, err := NewPayload(username, role, duration, tokenType)
+	if err != nil {
+		return "", err
+	}
+	return maker.CreateTokenFromPayload(payload)
+}
+
+func (maker *PasetoMaker) CreateTokenFromPayload(payload *Payload) (string, error) {
+	pasetoToken := paseto.NewToken()
+	pasetoToken.SetJti(payload.ID.String())
+	pasetoToken.SetSubject(payload.Username)
+	pasetoToken.SetIssuedAt(payload.IssuedAt)
+	pasetoToken.SetNotBefore(payload.IssuedAt)
+	pasetoToken.SetExpiration(payload.ExpiredAt)
+	pasetoToken.SetString("role", payload.Role)
+	if err := pasetoToken.Set("token_type", payload.Type); err != nil {
+		return "", err
+	}
+	if err := pasetoToken.Set("store_id", payload.StoreId); err != nil {
+		return "", err
+	}
+
+	return pasetoToken.V4Encrypt(maker.symmetricKey, nil), nil
+}
+
+// VerifyToken checks if the token is valid or not
+func (maker *PasetoMaker) VerifyToken(token string, tokenType TokenType) (*Payload, error) {
+	// Expiry is checked by payload.Valid so an expired token reports ErrExpiredToken
+	parser := paseto.NewParserWithoutExpiryCheck()
+	pasetoToken, err := parser.ParseV4Local(maker.symmetricKey, token, nil)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	payload, err := payloadFromPaseto(pasetoToken)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	err = payload.Valid(tokenType)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func payloadFromPaseto(pasetoToken *paseto.Token) (*Payload, error) {
+	var payload Payload
+	var err error
+
+	jti, err := pasetoToken.GetJti()
+	if err != nil {
+		return nil, err
+	}
+	if payload.ID, err = uuid.Parse(jti); err != nil {
+		return nil, err
+	}
+	if payload.Username, err = pasetoToken.GetSubject(); err != nil {
+		return nil, err
+	}
+	if payload.Role, err = pasetoToken.GetString("role"); err != nil {
+		return nil, err
+	}
+	if err = pasetoToken.Get("token_type", &payload.Type); err != nil {
+		return nil, err
+	}
+	if err = pasetoToken.Get("store_id", &payload.StoreId); err != nil {
+		return nil, err
+	}
+	if payload.IssuedAt, err = pasetoToken.GetIssuedAt(); err != nil {
+		return nil, err
+	}
+	if payload.ExpiredAt, err = pasetoToken.GetExpiration(); err != nil {
+		return nil, err
+	}
+
+	return &payload, nil
+}
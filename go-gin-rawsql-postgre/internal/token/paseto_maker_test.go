@@ -0,0 +1,39 @@
+package token
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPasetoMaker(t *testing.T) {
+	maker, err := NewPasetoMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+	require.NotNil(t, maker)
+
+	maker, err = NewPasetoMaker("short-key")
+	require.Error(t, err)
+	require.Nil(t, maker)
+}
+
+func TestPasetoMaker_CreateToken(t *testing.T) {
+	maker, err := NewPasetoMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	payload, err := NewPayload("testuser", "user", time.Minute, TokenTypeRefreshToken)
+	require.NoError(t, err)
+	payload.StoreId = 2
+
+	token, err := maker.CreateTokenFromPayload(payload)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(token, "v4.local."))
+
+	verified, err := maker.VerifyToken(token, TokenTypeRefreshToken)
+	require.NoError(t, err)
+	require.Equal(t, payload.ID, verified.ID)
+	require.Equal(t, payload.Type, verified.Type)
+	require.Equal(t, payload.StoreId, verified.StoreId)
+	require.WithinDuration(t, payload.ExpiredAt, verified.ExpiredAt, time.Second)
+}
@@ -19,6 +19,9 @@ type TokenType byte
 const (
 	TokenTypeAccessToken  = 1
 	TokenTypeRefreshToken = 2
+	// TokenTypeMFAPendingToken proves the password step of a login and is exchanged
+	// for an access and refresh token once the second factor is verified
+	TokenTypeMFAPendingToken = 3
 )
 
 // Payload contains the payload data of the token
@@ -27,6 +30,7 @@ type Payload struct {
 	Type      TokenType `json:"token_type"`
 	Username  string    `json:"username"`
 	Role      string    `json:"role"`
+	StoreId   int       `json:"store_id,omitempty"`
 	IssuedAt  time.Time `json:"issued_at"`
 	ExpiredAt time.Time `json:"expired_at"`
 }
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"film-rental/internal/token/model"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+	ErrRefreshTokenRevoked  = errors.New("refresh token has been revoked")
+	ErrRefreshTokenReused   = errors.New("refresh token has already been used")
+)
+
+func InsertRefreshToken(token model.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (id, family_id, username, issued_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	_, err := dbRaw.DB.Exec(query, token.ID, token.FamilyID, token.Username, token.IssuedAt, token.ExpiresAt)
+	return err
+}
+
+// RotateRefreshToken marks the refresh token oldID as used and stores next in the same
+// family. A token can only be rotated once: presenting an already used token is
+// treated as theft, so the whole family is revoked and ErrRefreshTokenReused returned.
+func RotateRefreshToken(oldID uuid.UUID, next model.RefreshToken, now time.Time) error {
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var familyID uuid.UUID
+	var usedAt, revokedAt *time.Time
+	err = tx.QueryRow(`SELECT family_id, used_at, revoked_at FROM refresh_tokens WHERE id = $1 FOR UPDATE`, oldID).
+		Scan(&familyID, &usedAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return ErrRefreshTokenNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if revokedAt != nil {
+		return ErrRefreshTokenRevoked
+	}
+	if usedAt != nil {
+		if _, err := tx.Exec(`UPDATE refresh_tokens SET revoked_at = $1 WHERE family_id = $2 AND revoked_at IS NULL`, now, familyID); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		return ErrRefreshTokenReused
+	}
+
+	if _, err := tx.Exec(`UPDATE refresh_tokens SET used_at = $1 WHERE id = $2`, now, oldID); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO refresh_tokens (id, family_id, username, issued_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	if _, err := tx.Exec(query, next.ID, familyID, next.Username, next.IssuedAt, next.ExpiresAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RevokeFamily revokes the session the refresh token id belongs to
+func RevokeFamily(id uuid.UUID, now time.Time) error {
+	query := `
+		UPDATE refresh_tokens SET revoked_at = $1
+		WHERE family_id = (SELECT family_id FROM refresh_tokens WHERE id = $2) AND revoked_at IS NULL
+	`
+	result, err := dbRaw.DB.Exec(query, now, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRefreshTokenNotFound
+	}
+
+	return nil
+}
+
+// RevokeAllForUser revokes every refresh token session of a user and returns how many
+// tokens were revoked
+func RevokeAllForUser(username string, now time.Time) (int64, error) {
+	result, err := dbRaw.DB.Exec(`UPDATE refresh_tokens SET revoked_at = $1 WHERE username = $2 AND revoked_at IS NULL`, now, username)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,75 @@
+package repository_test
+
+import (
+	"film-rental/internal/token/model"
+	"film-rental/internal/token/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+)
+
+func TestRotateRefreshToken_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	now := time.Now()
+	oldID, familyID := uuid.New(), uuid.New()
+	next := model.RefreshToken{ID: uuid.New(), Username: "admin", IssuedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT family_id, used_at, revoked_at FROM refresh_tokens WHERE id = \$1 FOR UPDATE`).
+		WithArgs(oldID).
+		WillReturnRows(sqlmock.NewRows([]string{"family_id", "used_at", "revoked_at"}).AddRow(familyID, nil, nil))
+	mock.ExpectExec(`UPDATE refresh_tokens SET used_at = \$1 WHERE id = \$2`).
+		WithArgs(now, oldID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO refresh_tokens`).
+		WithArgs(next.ID, familyID, next.Username, next.IssuedAt, next.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repository.RotateRefreshToken(oldID, next, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestRotateRefreshToken_ReuseRevokesFamily(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	now := time.Now()
+	oldID, familyID := uuid.New(), uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT family_id, used_at, revoked_at FROM refresh_tokens WHERE id = \$1 FOR UPDATE`).
+		WithArgs(oldID).
+		WillReturnRows(sqlmock.NewRows([]string{"family_id", "used_at", "revoked_at"}).AddRow(familyID, now.Add(-time.Minute), nil))
+	mock.ExpectExec(`UPDATE refresh_tokens SET revoked_at = \$1 WHERE family_id = \$2 AND revoked_at IS NULL`).
+		WithArgs(now, familyID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	err = repository.RotateRefreshToken(oldID, model.RefreshToken{ID: uuid.New()}, now)
+	if err != repository.ErrRefreshTokenReused {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
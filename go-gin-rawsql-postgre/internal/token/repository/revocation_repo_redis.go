@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"errors"
+	"film-rental/internal/token"
+	"film-rental/pkg/redis"
+	"time"
+
+	"github.com/google/uuid"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	denylistKeyPrefix      = "token:denylist:"
+	revokedBeforeKeyPrefix = "token:revoked_before:"
+)
+
+// RevokeAccessToken denylists a single token until it expires on its own
+func RevokeAccessToken(id uuid.UUID, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return redis.Rdb.Set(redis.Ctx, denylistKeyPrefix+id.String(), 1, ttl).Err()
+}
+
+// RevokeUserTokens invalidates every token of username issued before the given time
+func RevokeUserTokens(username string, before time.Time) error {
+	return redis.Rdb.Set(redis.Ctx, revokedBeforeKeyPrefix+username, before.UnixNano(), 0).Err()
+}
+
+// IsTokenRevoked checks the token ID denylist and the user's "issued before" watermark
+func IsTokenRevoked(payload *token.Payload) (bool, error) {
+	denylisted, err := redis.Rdb.Exists(redis.Ctx, denylistKeyPrefix+payload.ID.String()).Result()
+	if err != nil {
+		return false, err
+	}
+	if denylisted > 0 {
+		return true, nil
+	}
+
+	revokedBefore, err := redis.Rdb.Get(redis.Ctx, revokedBeforeKeyPrefix+payload.Username).Int64()
+	if errors.Is(err, goredis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return payload.IssuedAt.UnixNano() < revokedBefore, nil
+}
+
+// RedisRevocationChecker is the token.RevocationChecker backed by the Redis denylist
+type RedisRevocationChecker struct{}
+
+func (RedisRevocationChecker) IsRevoked(payload *token.Payload) (bool, error) {
+	return IsTokenRevoked(payload)
+}
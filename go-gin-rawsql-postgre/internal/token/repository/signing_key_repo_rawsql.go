@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"crypto/x509"
+	"database/sql"
+	"film-rental/internal/token"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/secretbox"
+	"time"
+)
+
+// signingKeyLockKey is the advisory lock held while storing a rotated key, so instances
+// rotating at the same time store one key between them
+const signingKeyLockKey = 7_311_954_021
+
+// SigningKeyStore keeps the JWT signing keys in Postgres, sealed with Box
+type SigningKeyStore struct {
+	Box *secretbox.Box
+}
+
+func (s SigningKeyStore) LoadKeys(algorithm string) ([]token.StoredKey, error) {
+	rows, err := dbRaw.DB.Query(`SELECT private_key, activates_at FROM signing_keys WHERE algorithm = $1`, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []token.StoredKey
+	for rows.Next() {
+		var sealed []byte
+		var key token.StoredKey
+		if err := rows.Scan(&sealed, &key.ActivatesAt); err != nil {
+			return nil, err
+		}
+		der, err := s.Box.Open(sealed)
+		if err != nil {
+			return nil, err
+		}
+		if key.Private, err = token.ParsePrivateKey(der); err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s SigningKeyStore) AddKey(algorithm string, key token.StoredKey, latest time.Time) (bool, error) {
+	kid, err := token.KeyID(key.Private.Public())
+	if err != nil {
+		return false, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(key.Private)
+	if err != nil {
+		return false, err
+	}
+	sealed, err := s.Box.Seal(der)
+	if err != nil {
+		return false, err
+	}
+
+	tx, err := dbRaw.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`SELECT pg_advisory_xact_lock($1)`, signingKeyLockKey); err != nil {
+		return false, err
+	}
+
+	var current sql.NullTime
+	if err := tx.QueryRow(`SELECT MAX(activates_at) FROM signing_keys WHERE algorithm = $1`, algorithm).Scan(&current); err != nil {
+		return false, err
+	}
+	if current.Valid != !latest.IsZero() || (current.Valid && !current.Time.Equal(latest)) {
+		return false, nil
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO signing_keys (kid, algorithm, private_key, activates_at, created_at)
+		VALUES ($1, $2, $3, $4, $5)`, kid, algorithm, sealed, key.ActivatesAt, time.Now())
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+func (s SigningKeyStore) PruneKeys(algorithm string, before time.Time) error {
+	_, err := dbRaw.DB.Exec(`
+		DELETE FROM signing_keys k
+		WHERE k.algorithm = $1 AND EXISTS (
+			SELECT 1 FROM signing_keys n
+			WHERE n.algorithm = k.algorithm AND n.activates_at > k.activates_at AND n.activates_at < $2
+		)`, algorithm, before)
+	return err
+}
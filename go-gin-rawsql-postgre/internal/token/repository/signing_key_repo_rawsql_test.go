@@ -0,0 +1,133 @@
+package repository_test
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"film-rental/internal/token"
+	"film-rental/internal/token/repository"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/secretbox"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newSigningKeyStore(t *testing.T) repository.SigningKeyStore {
+	box, err := secretbox.New(bytes.Repeat([]byte{7}, secretbox.KeySize))
+	if err != nil {
+		t.Fatalf("failed to create box: %v", err)
+	}
+	return repository.SigningKeyStore{Box: box}
+}
+
+func TestSigningKeyStore_AddKey_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	store := newSigningKeyStore(t)
+	privateKey, err := token.GenerateKey(token.AlgorithmEdDSA)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	latest := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	key := token.StoredKey{Private: privateKey, ActivatesAt: latest.Add(24 * time.Hour)}
+
+	// Test case 1: the newest key is still the one this instance saw
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT MAX\(activates_at\) FROM signing_keys WHERE algorithm = \$1`).
+		WithArgs(token.AlgorithmEdDSA).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(latest))
+	mock.ExpectExec(`INSERT INTO signing_keys`).
+		WithArgs(sqlmock.AnyArg(), token.AlgorithmEdDSA, sqlmock.AnyArg(), key.ActivatesAt, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	stored, err := store.AddKey(token.AlgorithmEdDSA, key, latest)
+	if err != nil || !stored {
+		t.Fatalf("expected key to be stored, got %v, %v", stored, err)
+	}
+
+	// Test case 2: another instance rotated first
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT MAX\(activates_at\) FROM signing_keys WHERE algorithm = \$1`).
+		WithArgs(token.AlgorithmEdDSA).
+		WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(key.ActivatesAt))
+	mock.ExpectRollback()
+
+	stored, err = store.AddKey(token.AlgorithmEdDSA, key, latest)
+	if err != nil || stored {
+		t.Fatalf("expected key not to be stored, got %v, %v", stored, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestSigningKeyStore_LoadKeys_Mock(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("failed to open sqlmock: %s", err)
+	}
+	defer mockDB.Close()
+	dbRaw.DB = mockDB
+
+	store := newSigningKeyStore(t)
+	privateKey, err := token.GenerateKey(token.AlgorithmRS256)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	// Capture the sealed key the store writes, then read it back
+	var sealed []byte
+	mock.ExpectBegin()
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`SELECT MAX\(activates_at\)`).WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+	mock.ExpectExec(`INSERT INTO signing_keys`).
+		WithArgs(sqlmock.AnyArg(), token.AlgorithmRS256, capture{&sealed}, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	activatesAt := time.Now()
+	if _, err := store.AddKey(token.AlgorithmRS256, token.StoredKey{Private: privateKey, ActivatesAt: activatesAt}, time.Time{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mock.ExpectQuery(`SELECT private_key, activates_at FROM signing_keys WHERE algorithm = \$1`).
+		WithArgs(token.AlgorithmRS256).
+		WillReturnRows(sqlmock.NewRows([]string{"private_key", "activates_at"}).AddRow(sealed, activatesAt))
+
+	keys, err := store.LoadKeys(token.AlgorithmRS256)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	wantKid, _ := token.KeyID(privateKey.Public())
+	if len(keys) != 1 {
+		t.Fatalf("expected one key, got %d", len(keys))
+	}
+	if kid, _ := token.KeyID(keys[0].Private.Public()); kid != wantKid {
+		t.Fatalf("loaded key %s, want %s", kid, wantKid)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+// capture is an argument matcher that keeps the value it matched
+type capture struct {
+	value *[]byte
+}
+
+func (c capture) Match(v driver.Value) bool {
+	b, ok := v.([]byte)
+	*c.value = b
+	return ok
+}
@@ -0,0 +1,6 @@
+package token
+
+// RevocationChecker reports whether a verified token has been revoked before its expiry
+type RevocationChecker interface {
+	IsRevoked(payload *Payload) (bool, error)
+}
@@ -1,20 +1,44 @@
 package main
 
 import (
+	"context"
+	"errors"
+	roleRepository "film-rental/internal/role/repository"
 	"film-rental/internal/router"
+	staffRepository "film-rental/internal/staff/repository"
 	token "film-rental/internal/token"
+	tokenModel "film-rental/internal/token/model"
+	tokenRepository "film-rental/internal/token/repository"
+	"film-rental/pkg/blob"
 	dbOrm "film-rental/pkg/db/gorm"
 	dbRaw "film-rental/pkg/db/raw-sql"
 	"film-rental/pkg/kafka"
+	"film-rental/pkg/mailer"
+	"film-rental/pkg/middleware"
 	"film-rental/pkg/mqtt"
 	"film-rental/pkg/redis"
+	"film-rental/pkg/secretbox"
+	"film-rental/util"
+	"film-rental/validator"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"golang.org/x/crypto/bcrypt"
 )
 
+// shutdownTimeout bounds how long in-flight requests and messages may take to finish
+// after SIGINT or SIGTERM
+const shutdownTimeout = 30 * time.Second
+
 func main() {
 	// Try to load .env file (for local development), but don't fail if it doesn't exist
 	err := godotenv.Load()
@@ -22,28 +46,214 @@ func main() {
 		log.Println("Warning: .env file not found, using environment variables")
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	mailer.Default, err = mailer.New(mailer.NewSMTPSenderFromEnv())
+	if err != nil {
+		log.Fatalf("Failed to create mailer: %v", err)
+	}
+
+	secretbox.Default, err = secretbox.NewFromEnv()
+	if errors.Is(err, secretbox.ErrNotConfigured) {
+		log.Println("Warning: SECRET_ENCRYPTION_KEY is not set, MFA and TOKEN_KEY_ROTATION_INTERVAL are unavailable")
+	} else if err != nil {
+		log.Fatalf("Failed to configure secret encryption: %v", err)
+	}
+
+	if err := configurePasswords(); err != nil {
+		log.Fatalf("Failed to configure passwords: %v", err)
+	}
+
 	dbRaw.InitDB(os.Getenv("DATABASE_URL"))
 	dbOrm.Connect(os.Getenv("DATABASE_URL"))
 
+	sealed, err := staffRepository.EncryptMFASecrets()
+	if err != nil {
+		log.Fatalf("Failed to encrypt MFA secrets: %v", err)
+	}
+	if sealed > 0 {
+		log.Printf("Sealed %d MFA secrets stored before encryption", sealed)
+	}
+
 	kafka.InitKafkaProducer()
+	redis.InitRedis()
+
+	consumerConfig, err := kafka.LoadConsumerConfig("film-consumer")
+	if err != nil {
+		log.Fatalf("Failed to configure Kafka consumer: %v", err)
+	}
+	filmConsumer := kafka.NewConsumer(consumerConfig)
+	filmConsumer.UseDedupStore(kafka.NewRedisDedupStore(consumerConfig.GroupID, consumerConfig.DedupTTL))
+	kafka.RegisterFilmHandlers(filmConsumer)
 
-	// Start consumers
-	go kafka.StartFilmConsumer("Consumer-1")
-	go kafka.StartFilmConsumer("Consumer-2")
-	go kafka.StartFilmConsumer("Consumer-3")
+	// Background workers stop when ctx is cancelled; shutdown waits for them
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		if err := filmConsumer.Run(ctx); err != nil {
+			log.Printf("❌ Film consumer stopped with error: %v", err)
+		}
+	}()
+	go func() {
+		defer workers.Done()
+		kafka.StartOutboxRelay(ctx)
+	}()
 	go kafka.StartMetricsServer()
 	go mqtt.StartMQTTSubscriber()
-	redis.InitRedis()
+
+	blobDir := os.Getenv("BLOB_STORE_DIR")
+	if blobDir == "" {
+		blobDir = "./data/blobs"
+	}
+	blob.DefaultStore, err = blob.NewLocalStore(blobDir)
+	if err != nil {
+		log.Fatalf("Failed to create blob store: %v", err)
+	}
+
+	if err := roleRepository.SeedDefaults(tokenModel.RolePermissions); err != nil {
+		log.Fatalf("Failed to seed roles: %v", err)
+	}
+	permissionCache := roleRepository.NewPermissionCache(time.Minute)
+	roleRepository.DefaultPermissionCache = permissionCache
+	go permissionCache.Subscribe(ctx)
+	tokenModel.SetPermissionSource(permissionCache)
 
 	r := gin.Default()
-	jwtMaker, err := token.NewJWTMaker(os.Getenv("TOKEN_SYMMETRIC_KEY"))
+	tokenMaker, err := newTokenMaker(ctx)
 	if err != nil {
-		log.Fatalf("Failed to create JWT maker: %v", err)
+		log.Fatalf("Failed to create token maker: %v", err)
 	}
-	router.RegisterRoutes(r, jwtMaker)
+	middleware.SetRevocationChecker(tokenRepository.RedisRevocationChecker{})
+	router.RegisterRoutes(r, tokenMaker)
 	r.Use(CORSMiddleware())
 
-	r.Run(":8080")
+	server := &http.Server{Addr: ":8080", Handler: r}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server error: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down...")
+	shutdown(server, &workers)
+}
+
+// shutdown stops accepting requests, then waits for in-flight requests and the
+// background workers to drain, giving up after shutdownTimeout
+func shutdown(server *http.Server, workers *sync.WaitGroup) {
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("❌ Failed to shut down the server cleanly: %v", err)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		log.Println("Shutdown complete")
+	case <-shutdownCtx.Done():
+		log.Println("❌ Timed out waiting for background workers to drain")
+	}
+}
+
+// configurePasswords applies the password policy from the environment, an optional
+// BREACHED_PASSWORDS_FILE replacing the bundled list, and BCRYPT_COST for new hashes
+func configurePasswords() error {
+	policy, err := validator.LoadPasswordPolicy()
+	if err != nil {
+		return err
+	}
+	validator.SetPasswordPolicy(policy)
+
+	if path := os.Getenv("BREACHED_PASSWORDS_FILE"); path != "" {
+		if err := validator.LoadBreachedPasswords(path); err != nil {
+			return err
+		}
+	}
+
+	if raw := os.Getenv("BCRYPT_COST"); raw != "" {
+		cost, err := strconv.Atoi(raw)
+		if err != nil || cost < bcrypt.DefaultCost || cost > bcrypt.MaxCost {
+			return fmt.Errorf("invalid BCRYPT_COST: %q", raw)
+		}
+		util.PasswordCost = cost
+	}
+	return nil
+}
+
+// newTokenMaker builds the token maker. TOKEN_FORMAT=paseto issues PASETO v4.local tokens
+// with TOKEN_SYMMETRIC_KEY. Otherwise JWTs are signed per TOKEN_SIGNING_ALG: HS256 (the
+// default) uses TOKEN_SYMMETRIC_KEY; RS256 and EdDSA sign with TOKEN_PRIVATE_KEY_FILE, or
+// with keys shared through the database and rotated every TOKEN_KEY_ROTATION_INTERVAL.
+// With neither a key is generated that only this instance knows.
+func newTokenMaker(ctx context.Context) (token.Maker, error) {
+	if os.Getenv("TOKEN_FORMAT") == "paseto" {
+		return token.NewPasetoMaker(os.Getenv("TOKEN_SYMMETRIC_KEY"))
+	}
+
+	algorithm := os.Getenv("TOKEN_SIGNING_ALG")
+	if algorithm == "" || algorithm == "HS256" {
+		return token.NewJWTMaker(os.Getenv("TOKEN_SYMMETRIC_KEY"))
+	}
+
+	// Retired keys must outlive the longest token they signed (refresh tokens, 7 days)
+	maker, err := token.NewAsymmetricJWTMaker(algorithm, 7*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
+	keyFile := os.Getenv("TOKEN_PRIVATE_KEY_FILE")
+	interval := os.Getenv("TOKEN_KEY_ROTATION_INTERVAL")
+	switch {
+	case keyFile != "" && interval != "":
+		return nil, fmt.Errorf("TOKEN_PRIVATE_KEY_FILE and TOKEN_KEY_ROTATION_INTERVAL cannot be combined")
+
+	case keyFile != "":
+		privateKey, err := token.LoadPrivateKey(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := maker.AddKey(privateKey, time.Time{}); err != nil {
+			return nil, err
+		}
+
+	case interval != "":
+		rotationInterval, err := time.ParseDuration(interval)
+		if err != nil {
+			return nil, err
+		}
+		if rotationInterval <= token.KeyPublishAhead {
+			return nil, fmt.Errorf("TOKEN_KEY_ROTATION_INTERVAL must be longer than %s", token.KeyPublishAhead)
+		}
+		if secretbox.Default == nil {
+			return nil, fmt.Errorf("TOKEN_KEY_ROTATION_INTERVAL requires SECRET_ENCRYPTION_KEY")
+		}
+		store := tokenRepository.SigningKeyStore{Box: secretbox.Default}
+		if _, err := maker.RotateIfDue(store, rotationInterval, time.Now()); err != nil {
+			return nil, err
+		}
+		go maker.StartKeyRotation(ctx, store, rotationInterval)
+
+	default:
+		log.Println("Warning: no TOKEN_PRIVATE_KEY_FILE or TOKEN_KEY_ROTATION_INTERVAL, tokens only verify on this instance")
+		privateKey, err := token.GenerateKey(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := maker.AddKey(privateKey, time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+
+	return maker, nil
 }
 
 func CORSMiddleware() gin.HandlerFunc {
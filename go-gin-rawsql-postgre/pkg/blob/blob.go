@@ -0,0 +1,17 @@
+// Package blob stores binary objects such as staff pictures behind a small interface
+// so the local filesystem store can later be swapped for object storage.
+package blob
+
+import "errors"
+
+var ErrNotFound = errors.New("blob not found")
+
+// Store keeps blobs under slash separated keys such as "staff/1/picture.jpg"
+type Store interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	Delete(key string) error
+}
+
+// DefaultStore is the store used by the handlers, configured in main
+var DefaultStore Store
@@ -0,0 +1,33 @@
+package blob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put("staff/1/picture.jpg", []byte("image")))
+
+	data, err := store.Get("staff/1/picture.jpg")
+	require.NoError(t, err)
+	require.Equal(t, []byte("image"), data)
+
+	require.NoError(t, store.Delete("staff/1/picture.jpg"))
+	_, err = store.Get("staff/1/picture.jpg")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// Deleting twice is fine
+	require.NoError(t, store.Delete("staff/1/picture.jpg"))
+}
+
+func TestLocalStore_RejectsEscapingKeys(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.Error(t, store.Put("../outside", []byte("x")))
+	require.Error(t, store.Put("", []byte("x")))
+}
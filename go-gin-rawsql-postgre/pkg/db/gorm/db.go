@@ -3,7 +3,13 @@ package db
 import (
 	"log"
 
+	inventoryModel "film-rental/internal/inventory/model"
+	paymentModel "film-rental/internal/payment/model"
+	roleModel "film-rental/internal/role/model"
+	staffModel "film-rental/internal/staff/model"
+	tokenModel "film-rental/internal/token/model"
 	monitoringModel "film-rental/pkg/monitoring/model"
+	outboxModel "film-rental/pkg/outbox/model"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -26,7 +32,22 @@ func Connect(dsn string) error {
 		return err
 	}
 
-	if err := db.AutoMigrate(&monitoringModel.EventLog{}); err != nil {
+	if err := db.AutoMigrate(
+		&monitoringModel.EventLog{},
+		&inventoryModel.InventoryRetirement{},
+		&paymentModel.RentalLoss{},
+		&tokenModel.RefreshToken{},
+		&tokenModel.SigningKey{},
+		&roleModel.Role{},
+		&roleModel.Permission{},
+		&roleModel.RolePermission{},
+		&staffModel.StaffPicture{},
+		&staffModel.MFAEnrollment{},
+		&staffModel.MFARecoveryCode{},
+		&staffModel.PasswordResetToken{},
+		&staffModel.PasswordHistory{},
+		&outboxModel.OutboxMessage{},
+	); err != nil {
 		return err
 	}
 
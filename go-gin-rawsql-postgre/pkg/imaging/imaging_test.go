@@ -0,0 +1,31 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeAndFit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 200))))
+
+	img, err := Decode(buf.Bytes())
+	require.NoError(t, err)
+
+	thumbnail, err := FitJPEG(img, 100)
+	require.NoError(t, err)
+
+	resized, err := Decode(thumbnail)
+	require.NoError(t, err)
+	require.Equal(t, 100, resized.Bounds().Dx())
+	require.Equal(t, 50, resized.Bounds().Dy())
+}
+
+func TestDecode_RejectsNonImages(t *testing.T) {
+	_, err := Decode([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	require.ErrorIs(t, err, ErrUnsupportedType)
+}
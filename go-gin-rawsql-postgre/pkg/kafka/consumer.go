@@ -2,66 +2,297 @@ package kafka
 
 import (
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	db "film-rental/pkg/db/gorm"
 	"film-rental/pkg/monitoring/model"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"log"
-	"math/rand"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-const (
-	maxRetries       = 2
-	retryDelayMillis = 2000
-)
+// Handler processes one message. An error retries the message with backoff, and after
+// the last attempt sends it to the dead-letter topic.
+type Handler func(ctx context.Context, msg kafka.Message) error
+
+type messageReader interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// recordEvent writes to the event log; tests replace it
+var recordEvent = logEvent
+
+// Consumer reads a topic as part of a consumer group and dispatches each message to the
+// handler registered for its event-type header. An offset is committed only once the
+// message and every earlier one of its partition were handled or dead-lettered.
+type Consumer struct {
+	config   ConsumerConfig
+	reader   messageReader
+	dlq      messageWriter
+	handlers map[string]Handler
+	fallback Handler
+	dedup    DedupStore
+}
 
-func StartFilmConsumer(consumerName string) {
+// NewConsumer creates a consumer for config. Register handlers before calling Run.
+func NewConsumer(config ConsumerConfig) *Consumer {
 	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  []string{"localhost:9092"},
-		Topic:    TopicFilmEvents,
-		GroupID:  "film-consumer-group", // same group for all
+		Brokers:  config.Brokers,
+		Topic:    config.Topic,
+		GroupID:  config.GroupID,
 		MinBytes: 1,
 		MaxBytes: 10e6,
 	})
+	return newConsumer(config, reader, nil)
+}
 
-	log.Printf("[%s] Starting consumer...", consumerName)
+func newConsumer(config ConsumerConfig, reader messageReader, dlq messageWriter) *Consumer {
+	return &Consumer{
+		config:   config,
+		reader:   reader,
+		dlq:      dlq,
+		handlers: make(map[string]Handler),
+	}
+}
 
+// Handle registers the handler of messages whose event-type header is eventType
+func (c *Consumer) Handle(eventType string, handler Handler) {
+	c.handlers[eventType] = handler
+}
+
+// HandleDefault registers the handler of messages no other handler is registered for,
+// such as raw messages without an event type. Without one they are skipped.
+func (c *Consumer) HandleDefault(handler Handler) {
+	c.fallback = handler
+}
+
+// UseDedupStore makes the consumer skip events the store has seen handled. Without one
+// every delivery of an event is handled.
+func (c *Consumer) UseDedupStore(store DedupStore) {
+	c.dedup = store
+}
+
+// Run consumes until ctx is cancelled. It then stops fetching, waits for the messages
+// already fetched to be handled and committed, and closes the reader.
+func (c *Consumer) Run(ctx context.Context) error {
+	log.Printf("[%s] Starting consumer on %s (group %s, %d worker(s) per partition)...",
+		c.config.Name, c.config.Topic, c.config.GroupID, c.config.Concurrency)
+
+	// In-flight messages are finished during shutdown, so handlers and commits must not
+	// see the cancellation
+	workCtx := context.WithoutCancel(ctx)
+
+	var wg sync.WaitGroup
+	partitions := make(map[int]*partitionWorkers)
 	for {
-		msg, err := reader.ReadMessage(context.Background())
+		msg, err := c.reader.FetchMessage(ctx)
 		if err != nil {
-			log.Printf("[%s] Error reading message: %v", consumerName, err)
+			if ctx.Err() != nil || errors.Is(err, io.EOF) {
+				break
+			}
+			log.Printf("[%s] Error reading message: %v", c.config.Name, err)
 			continue
 		}
-		// log.Printf("[%s] Received message from partition %d: %s", consumerName, msg.Partition, string(msg.Value))
-
-		success := false
-		for attempt := 1; attempt <= maxRetries; attempt++ {
-			err := processMessage(msg)
-			if err != nil {
-				log.Printf("[%s] Retry %d/%d failed: %v", consumerName, attempt, maxRetries, err)
-				time.Sleep(retryDelayMillis * time.Millisecond)
-			} else {
-				success = true
-				break
+
+		workers, ok := partitions[msg.Partition]
+		if !ok {
+			workers = c.startPartitionWorkers(workCtx, &wg)
+			partitions[msg.Partition] = workers
+		}
+		workers.dispatch(msg)
+	}
+
+	log.Printf("[%s] Draining in-flight messages...", c.config.Name)
+	for _, workers := range partitions {
+		workers.close()
+	}
+	wg.Wait()
+
+	log.Printf("[%s] Consumer stopped", c.config.Name)
+	return c.reader.Close()
+}
+
+// partitionWorkers handles the messages of one partition. Messages with the same key
+// go to the same worker, so the events of a film are handled in order.
+type partitionWorkers struct {
+	consumer *Consumer
+	queues   []chan kafka.Message
+
+	// mu guards tracker and serializes commits so the committed offset never goes back
+	mu      sync.Mutex
+	tracker offsetTracker
+}
+
+func (c *Consumer) startPartitionWorkers(ctx context.Context, wg *sync.WaitGroup) *partitionWorkers {
+	workers := &partitionWorkers{consumer: c, queues: make([]chan kafka.Message, c.config.Concurrency)}
+	for i := range workers.queues {
+		queue := make(chan kafka.Message)
+		workers.queues[i] = queue
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for msg := range queue {
+				if c.handle(ctx, msg) {
+					workers.complete(ctx, msg)
+				}
 			}
+		}()
+	}
+	return workers
+}
+
+// dispatch queues msg for its worker, blocking while that worker is busy
+func (p *partitionWorkers) dispatch(msg kafka.Message) {
+	p.mu.Lock()
+	p.tracker.add(msg.Offset)
+	p.mu.Unlock()
+
+	p.queues[workerIndex(msg, len(p.queues))] <- msg
+}
+
+// complete commits up to the last offset before which every message has been handled
+func (p *partitionWorkers) complete(ctx context.Context, msg kafka.Message) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	offset, ok := p.tracker.complete(msg.Offset)
+	if !ok {
+		return
+	}
+	commit := kafka.Message{Topic: msg.Topic, Partition: msg.Partition, Offset: offset}
+	if err := p.consumer.reader.CommitMessages(ctx, commit); err != nil {
+		log.Printf("[%s] Failed to commit offset %d of partition %d: %v", p.consumer.config.Name, offset, msg.Partition, err)
+	}
+}
+
+func (p *partitionWorkers) close() {
+	for _, queue := range p.queues {
+		close(queue)
+	}
+}
+
+// workerIndex picks the worker of a message by key. Messages without a key, such as raw
+// messages bridged from MQTT, have no order to keep and are spread by offset.
+func workerIndex(msg kafka.Message, workers int) int {
+	if workers == 1 {
+		return 0
+	}
+	if len(msg.Key) == 0 {
+		return int(msg.Offset % int64(workers))
+	}
+	hash := fnv.New32a()
+	hash.Write(msg.Key)
+	return int(hash.Sum32() % uint32(workers))
+}
+
+// handle runs the message's handler unless its event was already handled. It reports
+// whether the offset may be committed; an uncommitted message is redelivered after a
+// restart or rebalance.
+func (c *Consumer) handle(ctx context.Context, msg kafka.Message) bool {
+	handler, ok := c.handlers[HeaderValue(msg, HeaderEventType)]
+	if !ok {
+		handler = c.fallback
+	}
+	if handler == nil {
+		log.Printf("[%s] No handler for event type %q, skipping offset %d", c.config.Name, HeaderValue(msg, HeaderEventType), msg.Offset)
+		return true
+	}
+
+	if c.dedup == nil {
+		_, commit := c.handleWithRetries(ctx, handler, msg)
+		return commit
+	}
+
+	id := eventID(msg)
+	status, err := c.claim(ctx, id)
+	if err != nil {
+		// Handling twice beats not handling at all
+		log.Printf("❌ [%s] Failed to check event %s for duplicates, handling it anyway: %v", c.config.Name, id, err)
+		_, commit := c.handleWithRetries(ctx, handler, msg)
+		return commit
+	}
+	switch status {
+	case ClaimDone:
+		duplicatesDetected.Add(1)
+		log.Printf("[%s] Skipping duplicate event %s at offset %d", c.config.Name, id, msg.Offset)
+		return true
+	case ClaimInProgress:
+		log.Printf("❌ [%s] Event %s at offset %d is still claimed, leaving it uncommitted", c.config.Name, id, msg.Offset)
+		return false
+	}
+
+	handled, commit := c.handleWithRetries(ctx, handler, msg)
+	if handled {
+		err = c.dedup.Complete(ctx, id)
+	} else {
+		err = c.dedup.Release(ctx, id)
+	}
+	if err != nil {
+		log.Printf("❌ [%s] Failed to record event %s in the dedup store: %v", c.config.Name, id, err)
+	}
+	return commit
+}
+
+// claim claims id, waiting while another worker holds it. The holder may be handling
+// the same event redelivered, or may have died, so the wait lasts until the claim is
+// completed, released or expired. A claim still held after dedupClaimTTL is reported
+// as in progress.
+func (c *Consumer) claim(ctx context.Context, id string) (ClaimStatus, error) {
+	deadline := time.Now().Add(dedupClaimTTL + dedupRetryDelay)
+	for {
+		status, err := c.dedup.Claim(ctx, id)
+		if err != nil || status != ClaimInProgress || time.Now().After(deadline) {
+			return status, err
+		}
+		time.Sleep(dedupRetryDelay)
+	}
+}
+
+// handleWithRetries runs handler with retries and dead-letters the message when every
+// attempt fails. It reports whether the message was handled, and whether it may be
+// committed: a message that could not be dead-lettered is not, so the commits of its
+// partition stop there and it is redelivered after a restart or rebalance.
+func (c *Consumer) handleWithRetries(ctx context.Context, handler Handler, msg kafka.Message) (handled bool, commit bool) {
+	var lastErr error
+	for attempt := 1; attempt <= c.config.MaxAttempts; attempt++ {
+		lastErr = handler(ctx, msg)
+		if lastErr == nil {
+			return true, true
 		}
-		
-		start := time.Now()
-		if !success {
-			logEvent(consumerName, "kafka_messages_failed", string(msg.Value))
-		} else {
-			logEvent(consumerName, "kafka_messages_processed", string(msg.Value))
+		log.Printf("[%s] Attempt %d/%d failed: %v", c.config.Name, attempt, c.config.MaxAttempts, lastErr)
+		if attempt < c.config.MaxAttempts {
+			time.Sleep(retryBackoff(attempt))
 		}
-		log.Printf("Insert took: %s", time.Since(start))
+	}
 
+	dead := NewDeadLetterMessage(msg, c.config.DLQTopic, c.config.Name, c.config.MaxAttempts, lastErr, time.Now())
+	dlq := c.dlq
+	if dlq == nil && writer != nil {
+		dlq = writer
 	}
+	// ctx is not cancelled on shutdown, so the retries are bounded on their own
+	dlqCtx, cancel := context.WithTimeout(ctx, dlqPublishTimeout)
+	defer cancel()
+	err := publishDeadLetter(dlqCtx, dlq, dead)
+	recordEvent(c.config.Name, "kafka_messages_failed", describeMessage(msg))
+	if err != nil {
+		log.Printf("❌ [%s] Failed to dead-letter message at offset %d, leaving it uncommitted: %v", c.config.Name, msg.Offset, err)
+		return false, false
+	}
+	return false, true
 }
 
-func logEvent(service, message, context string) {
+func logEvent(service, message, context string) error {
 	eventLog := model.EventLog{
 		Service: service,
 		Message: message,
@@ -69,18 +300,31 @@ func logEvent(service, message, context string) {
 	}
 	if err := db.DB.Create(&eventLog).Error; err != nil {
 		log.Printf("[%s] Failed to insert event log: %v", service, err)
+		return err
 	}
+	return nil
 }
 
-// Simulated business logic
-func processMessage(msg kafka.Message) error {
-	log.Printf("Processing message: %s", string(msg.Value))
+// isFilmEvent tells typed film events from raw messages bridged from MQTT
+func isFilmEvent(msg kafka.Message) bool {
+	return HeaderValue(msg, HeaderEventType) != ""
+}
 
-	// Simulate random failure for testing
-	if rand.Intn(2) == 0 {
-		return errors.New("simulated failure")
+// describeMessage renders a message for the event log. Protobuf values are binary, so
+// the decoded event is logged as JSON instead of the raw value.
+func describeMessage(msg kafka.Message) string {
+	if !isFilmEvent(msg) {
+		return strings.ToValidUTF8(strings.ReplaceAll(string(msg.Value), "\x00", ""), "")
 	}
-	return nil
+	event, err := DecodeFilmEvent(msg.Value, HeaderValue(msg, HeaderContentType))
+	if err != nil {
+		return base64.StdEncoding.EncodeToString(msg.Value)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return base64.StdEncoding.EncodeToString(msg.Value)
+	}
+	return string(data)
 }
 
 func StartMetricsServer() {
@@ -93,6 +337,8 @@ func StartMetricsServer() {
 		for _, e := range errorCounts {
 			fmt.Fprintf(w, "%s %d\n", e.Message, e.Count)
 		}
+		writeOutboxMetrics(w)
+		fmt.Fprintf(w, "kafka_duplicate_messages_total %d\n", duplicatesDetected.Load())
 	})
 
 	log.Println("Metrics available at http://localhost:9090/metrics")
@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const defaultBroker = "localhost:9092"
+
+// ConsumerConfig configures a Consumer
+type ConsumerConfig struct {
+	// Name identifies the consumer in logs and the event log
+	Name     string
+	Brokers  []string
+	Topic    string
+	GroupID  string
+	DLQTopic string
+	// Concurrency is the number of workers per partition
+	Concurrency int
+	// MaxAttempts is how often a message is tried before it is dead-lettered
+	MaxAttempts int
+	// DedupTTL is how long a handled event id is remembered
+	DedupTTL time.Duration
+}
+
+// LoadConsumerConfig reads the film-events consumer configuration from KAFKA_BROKERS
+// (comma separated), KAFKA_CONSUMER_TOPIC, KAFKA_CONSUMER_GROUP, KAFKA_CONSUMER_DLQ_TOPIC,
+// KAFKA_CONSUMER_CONCURRENCY, KAFKA_CONSUMER_MAX_ATTEMPTS and KAFKA_CONSUMER_DEDUP_TTL
+func LoadConsumerConfig(name string) (ConsumerConfig, error) {
+	config := ConsumerConfig{
+		Name:        name,
+		Brokers:     brokers(),
+		Topic:       envOrDefault("KAFKA_CONSUMER_TOPIC", TopicFilmEvents),
+		GroupID:     envOrDefault("KAFKA_CONSUMER_GROUP", "film-consumer-group"),
+		Concurrency: 1,
+		MaxAttempts: 3,
+		DedupTTL:    24 * time.Hour,
+	}
+	config.DLQTopic = DeadLetterTopic()
+
+	var err error
+	if config.Concurrency, err = positiveEnv("KAFKA_CONSUMER_CONCURRENCY", config.Concurrency); err != nil {
+		return config, err
+	}
+	if config.MaxAttempts, err = positiveEnv("KAFKA_CONSUMER_MAX_ATTEMPTS", config.MaxAttempts); err != nil {
+		return config, err
+	}
+	if raw := os.Getenv("KAFKA_CONSUMER_DEDUP_TTL"); raw != "" {
+		if config.DedupTTL, err = time.ParseDuration(raw); err != nil || config.DedupTTL <= 0 {
+			return config, fmt.Errorf("invalid KAFKA_CONSUMER_DEDUP_TTL: %q", raw)
+		}
+	}
+	return config, nil
+}
+
+// DeadLetterTopic returns KAFKA_CONSUMER_DLQ_TOPIC, by default the consumer topic with
+// .dlq appended
+func DeadLetterTopic() string {
+	return envOrDefault("KAFKA_CONSUMER_DLQ_TOPIC", envOrDefault("KAFKA_CONSUMER_TOPIC", TopicFilmEvents)+".dlq")
+}
+
+// brokers returns the KAFKA_BROKERS list, localhost:9092 by default
+func brokers() []string {
+	var list []string
+	for _, broker := range strings.Split(os.Getenv("KAFKA_BROKERS"), ",") {
+		if broker = strings.TrimSpace(broker); broker != "" {
+			list = append(list, broker)
+		}
+	}
+	if len(list) == 0 {
+		return []string{defaultBroker}
+	}
+	return list
+}
+
+func envOrDefault(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func positiveEnv(key string, fallback int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 1 {
+		return 0, fmt.Errorf("invalid %s: %q", key, raw)
+	}
+	return value, nil
+}
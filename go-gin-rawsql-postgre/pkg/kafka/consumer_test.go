@@ -0,0 +1,432 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeReader serves msgs, then blocks until the context is cancelled
+type fakeReader struct {
+	mu      sync.Mutex
+	msgs    []kafka.Message
+	commits map[int]int64
+	closed  bool
+}
+
+func (r *fakeReader) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	r.mu.Lock()
+	if len(r.msgs) > 0 {
+		msg := r.msgs[0]
+		r.msgs = r.msgs[1:]
+		r.mu.Unlock()
+		return msg, nil
+	}
+	r.mu.Unlock()
+
+	<-ctx.Done()
+	return kafka.Message{}, ctx.Err()
+}
+
+func (r *fakeReader) CommitMessages(ctx context.Context, msgs ...kafka.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, msg := range msgs {
+		if msg.Offset < r.commits[msg.Partition] {
+			return fmt.Errorf("commit of partition %d went back from %d to %d", msg.Partition, r.commits[msg.Partition], msg.Offset)
+		}
+		r.commits[msg.Partition] = msg.Offset
+	}
+	return nil
+}
+
+func (r *fakeReader) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *fakeReader) remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.msgs)
+}
+
+func stubRecordEvent(t *testing.T) *[]string {
+	var mu sync.Mutex
+	var messages []string
+	original := recordEvent
+	recordEvent = func(service, message, context string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, message)
+		return nil
+	}
+	t.Cleanup(func() { recordEvent = original })
+	return &messages
+}
+
+func testMessage(partition int, offset int64, eventType string, key string) kafka.Message {
+	msg := kafka.Message{Topic: TopicFilmEvents, Partition: partition, Offset: offset, Key: []byte(key)}
+	if eventType != "" {
+		msg.Headers = []kafka.Header{{Key: HeaderEventType, Value: []byte(eventType)}}
+	}
+	return msg
+}
+
+func testConfig(concurrency int) ConsumerConfig {
+	return ConsumerConfig{
+		Name:        "test-consumer",
+		Topic:       TopicFilmEvents,
+		GroupID:     "test-group",
+		DLQTopic:    TopicFilmEventsDLQ,
+		Concurrency: concurrency,
+		MaxAttempts: 1,
+	}
+}
+
+// runUntilFetched runs the consumer until every message was fetched, then cancels it
+// and waits for the drain
+func runUntilFetched(t *testing.T, c *Consumer, reader *fakeReader) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- c.Run(ctx) }()
+
+	require.Eventually(t, func() bool { return reader.remaining() == 0 }, time.Second, time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("consumer did not stop")
+	}
+}
+
+func TestConsumerDispatchesByEventType(t *testing.T) {
+	stubRecordEvent(t)
+
+	reader := &fakeReader{commits: map[int]int64{}, msgs: []kafka.Message{
+		testMessage(0, 0, FilmCreated, "1"),
+		testMessage(0, 1, FilmDeleted, "1"),
+		testMessage(0, 2, "", ""),
+		testMessage(0, 3, "film.unknown", "2"),
+	}}
+	c := newConsumer(testConfig(1), reader, &fakeWriter{})
+
+	var mu sync.Mutex
+	var handled []string
+	record := func(name string) Handler {
+		return func(ctx context.Context, msg kafka.Message) error {
+			mu.Lock()
+			defer mu.Unlock()
+			handled = append(handled, fmt.Sprintf("%s@%d", name, msg.Offset))
+			return nil
+		}
+	}
+	c.Handle(FilmCreated, record("created"))
+	c.Handle(FilmDeleted, record("deleted"))
+	c.HandleDefault(record("default"))
+
+	runUntilFetched(t, c, reader)
+
+	assert.Equal(t, []string{"created@0", "deleted@1", "default@2", "default@3"}, handled)
+	assert.Equal(t, int64(3), reader.commits[0])
+	assert.True(t, reader.closed)
+}
+
+func TestConsumerDrainsInFlightMessages(t *testing.T) {
+	stubRecordEvent(t)
+
+	var msgs []kafka.Message
+	for offset := int64(0); offset < 50; offset++ {
+		msgs = append(msgs, testMessage(int(offset%2), offset/2, FilmUpdated, fmt.Sprint(offset%7)))
+	}
+	reader := &fakeReader{commits: map[int]int64{}, msgs: msgs}
+	c := newConsumer(testConfig(4), reader, &fakeWriter{})
+
+	var mu sync.Mutex
+	handled := 0
+	c.Handle(FilmUpdated, func(ctx context.Context, msg kafka.Message) error {
+		time.Sleep(time.Duration(msg.Offset%3) * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		handled++
+		return nil
+	})
+
+	runUntilFetched(t, c, reader)
+
+	assert.Equal(t, 50, handled)
+	assert.Equal(t, int64(24), reader.commits[0])
+	assert.Equal(t, int64(24), reader.commits[1])
+}
+
+func TestConsumerDeadLettersFailedMessages(t *testing.T) {
+	events := stubRecordEvent(t)
+
+	reader := &fakeReader{commits: map[int]int64{}, msgs: []kafka.Message{
+		testMessage(0, 0, FilmCreated, "1"),
+		testMessage(0, 1, FilmCreated, "2"),
+	}}
+	dlq := &fakeWriter{}
+	config := testConfig(1)
+	config.MaxAttempts = 2
+	c := newConsumer(config, reader, dlq)
+
+	attempts := 0
+	c.Handle(FilmCreated, func(ctx context.Context, msg kafka.Message) error {
+		if msg.Offset == 0 {
+			attempts++
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	runUntilFetched(t, c, reader)
+
+	assert.Equal(t, 2, attempts)
+	require.Len(t, dlq.written, 1)
+	assert.Equal(t, TopicFilmEventsDLQ, dlq.written[0].Topic)
+	assert.Equal(t, "boom", HeaderValue(dlq.written[0], HeaderDLQError))
+	assert.Equal(t, "2", HeaderValue(dlq.written[0], HeaderDLQAttempts))
+	assert.Equal(t, []string{"kafka_messages_failed"}, *events)
+	assert.Equal(t, int64(1), reader.commits[0])
+}
+
+func TestConsumerDoesNotCommitUndeadLetteredMessages(t *testing.T) {
+	stubRecordEvent(t)
+
+	reader := &fakeReader{commits: map[int]int64{}, msgs: []kafka.Message{
+		testMessage(0, 0, FilmCreated, "1"),
+		testMessage(0, 1, FilmCreated, "2"),
+	}}
+	// Neither a DLQ writer nor the producer is set up, so dead-lettering fails
+	c := newConsumer(testConfig(1), reader, nil)
+	c.Handle(FilmCreated, func(ctx context.Context, msg kafka.Message) error {
+		if msg.Offset == 0 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	runUntilFetched(t, c, reader)
+
+	// Offset 0 is neither handled nor in the DLQ, so nothing of its partition is committed
+	_, committed := reader.commits[0]
+	assert.False(t, committed)
+}
+
+// memoryDedupStore keeps claims in a map
+type memoryDedupStore struct {
+	mu     sync.Mutex
+	claims map[string]string
+}
+
+func (s *memoryDedupStore) Claim(ctx context.Context, id string) (ClaimStatus, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch s.claims[id] {
+	case dedupDone:
+		return ClaimDone, nil
+	case dedupProcessing:
+		return ClaimInProgress, nil
+	}
+	s.claims[id] = dedupProcessing
+	return ClaimAcquired, nil
+}
+
+func (s *memoryDedupStore) Complete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.claims[id] = "done"
+	return nil
+}
+
+func (s *memoryDedupStore) Release(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.claims, id)
+	return nil
+}
+
+func withEventID(msg kafka.Message, id string) kafka.Message {
+	msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderEventID, Value: []byte(id)})
+	return msg
+}
+
+func TestConsumerSkipsDuplicateEvents(t *testing.T) {
+	stubRecordEvent(t)
+	duplicatesBefore := duplicatesDetected.Load()
+
+	reader := &fakeReader{commits: map[int]int64{}, msgs: []kafka.Message{
+		withEventID(testMessage(0, 0, FilmCreated, "1"), "a"),
+		withEventID(testMessage(0, 1, FilmCreated, "1"), "a"),
+		withEventID(testMessage(0, 2, FilmCreated, "2"), "b"),
+		withEventID(testMessage(0, 3, FilmCreated, "3"), "c"),
+		withEventID(testMessage(0, 4, FilmCreated, "3"), "c"),
+		testMessage(0, 5, "", ""),
+	}}
+	store := &memoryDedupStore{claims: map[string]string{}}
+	c := newConsumer(testConfig(1), reader, &fakeWriter{})
+	c.UseDedupStore(store)
+
+	var handled []int64
+	handler := func(ctx context.Context, msg kafka.Message) error {
+		handled = append(handled, msg.Offset)
+		if HeaderValue(msg, HeaderEventID) == "c" && msg.Offset == 3 {
+			return errors.New("boom")
+		}
+		return nil
+	}
+	c.Handle(FilmCreated, handler)
+	c.HandleDefault(handler)
+
+	runUntilFetched(t, c, reader)
+
+	// c failed and was released, so its redelivery is handled again
+	assert.Equal(t, []int64{0, 2, 3, 4, 5}, handled)
+	assert.Equal(t, int64(1), duplicatesDetected.Load()-duplicatesBefore)
+	assert.Equal(t, map[string]string{
+		"a": "done", "b": "done", "c": "done",
+		TopicFilmEvents + "/0/5": "done",
+	}, store.claims)
+	assert.Equal(t, int64(5), reader.commits[0])
+}
+
+func shortDedupRetryDelay(t *testing.T) {
+	original := dedupRetryDelay
+	dedupRetryDelay = time.Millisecond
+	t.Cleanup(func() { dedupRetryDelay = original })
+}
+
+// crashedClaimStore holds a claim left behind by a consumer that died while handling
+// the event, which expires after the claim was found in progress a few times
+type crashedClaimStore struct {
+	*memoryDedupStore
+	waits int
+}
+
+func (s *crashedClaimStore) Claim(ctx context.Context, id string) (ClaimStatus, error) {
+	status, err := s.memoryDedupStore.Claim(ctx, id)
+	if status == ClaimInProgress {
+		s.waits++
+		if s.waits == 3 {
+			s.mu.Lock()
+			delete(s.claims, id)
+			s.mu.Unlock()
+		}
+	}
+	return status, err
+}
+
+func TestConsumerWaitsForCrashedClaim(t *testing.T) {
+	stubRecordEvent(t)
+	duplicatesBefore := duplicatesDetected.Load()
+	shortDedupRetryDelay(t)
+
+	reader := &fakeReader{commits: map[int]int64{}, msgs: []kafka.Message{
+		withEventID(testMessage(0, 0, FilmCreated, "1"), "a"),
+		withEventID(testMessage(0, 1, FilmCreated, "2"), "b"),
+	}}
+	store := &crashedClaimStore{memoryDedupStore: &memoryDedupStore{claims: map[string]string{"a": dedupProcessing}}}
+	c := newConsumer(testConfig(1), reader, &fakeWriter{})
+	c.UseDedupStore(store)
+
+	var handled []int64
+	c.Handle(FilmCreated, func(ctx context.Context, msg kafka.Message) error {
+		handled = append(handled, msg.Offset)
+		return nil
+	})
+
+	runUntilFetched(t, c, reader)
+
+	// The redelivery is handled once the dead consumer's claim expired, not skipped
+	assert.Equal(t, []int64{0, 1}, handled)
+	assert.Equal(t, 3, store.waits)
+	assert.Equal(t, int64(0), duplicatesDetected.Load()-duplicatesBefore)
+	assert.Equal(t, map[string]string{"a": dedupDone, "b": dedupDone}, store.claims)
+	assert.Equal(t, int64(1), reader.commits[0])
+}
+
+func TestOffsetTracker(t *testing.T) {
+	var tracker offsetTracker
+	for offset := int64(10); offset < 14; offset++ {
+		tracker.add(offset)
+	}
+
+	_, ok := tracker.complete(12)
+	assert.False(t, ok)
+	_, ok = tracker.complete(11)
+	assert.False(t, ok)
+
+	offset, ok := tracker.complete(10)
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), offset)
+
+	offset, ok = tracker.complete(13)
+	assert.True(t, ok)
+	assert.Equal(t, int64(13), offset)
+}
+
+func TestWorkerIndex(t *testing.T) {
+	msg := testMessage(0, 5, FilmUpdated, "42")
+	index := workerIndex(msg, 4)
+	for offset := int64(0); offset < 10; offset++ {
+		msg.Offset = offset
+		assert.Equal(t, index, workerIndex(msg, 4), "same key, same worker")
+	}
+
+	raw := testMessage(0, 6, "", "")
+	assert.Equal(t, 2, workerIndex(raw, 4))
+	assert.Equal(t, 0, workerIndex(raw, 1))
+}
+
+func TestConsumerProcessesEachBridgedMessageOnce(t *testing.T) {
+	events := stubRecordEvent(t)
+	shortDedupRetryDelay(t)
+	duplicatesBefore := duplicatesDetected.Load()
+
+	// 1000 messages bridged from MQTT, every fifth redelivered by the broker and so
+	// published again with the same id, and two identical messages without an id
+	var msgs []kafka.Message
+	offsets := map[int]int64{}
+	publish := func(payload string, id string) {
+		msg := newRawMessage([]byte(payload), "text/plain", id)
+		msg.Partition = len(msgs) % 3
+		msg.Offset = offsets[msg.Partition]
+		offsets[msg.Partition]++
+		msgs = append(msgs, msg)
+	}
+	for i := 0; i < 1000; i++ {
+		payload := fmt.Sprintf(`{"id":"%d","message":"test message %d"}`, i, i)
+		publish(payload, fmt.Sprintf("mqtt:%d", i))
+		if i%5 == 0 {
+			publish(payload, fmt.Sprintf("mqtt:%d", i))
+		}
+	}
+	publish("same payload", "")
+	publish("same payload", "")
+
+	reader := &fakeReader{commits: map[int]int64{}, msgs: msgs}
+	c := newConsumer(testConfig(4), reader, &fakeWriter{})
+	c.UseDedupStore(&memoryDedupStore{claims: map[string]string{}})
+	RegisterFilmHandlers(c)
+
+	runUntilFetched(t, c, reader)
+
+	processed := 0
+	for _, event := range *events {
+		if event == "kafka_messages_processed" {
+			processed++
+		}
+	}
+	assert.Equal(t, 1002, processed)
+	assert.Equal(t, int64(200), duplicatesDetected.Load()-duplicatesBefore)
+}
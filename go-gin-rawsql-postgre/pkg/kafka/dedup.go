@@ -0,0 +1,104 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"film-rental/pkg/redis"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/segmentio/kafka-go"
+)
+
+// HeaderEventID identifies an event across redeliveries. Consumers deduplicate by it.
+const HeaderEventID = "event-id"
+
+const (
+	dedupKeyPrefix = "kafka:dedup:"
+	// dedupClaimTTL frees the claim of a consumer that died while handling the event
+	dedupClaimTTL = 5 * time.Minute
+
+	dedupProcessing = "processing"
+	dedupDone       = "done"
+)
+
+// ClaimStatus is the outcome of claiming an event id
+type ClaimStatus int
+
+const (
+	// ClaimAcquired means the caller now handles the event
+	ClaimAcquired ClaimStatus = iota
+	// ClaimDone means the event was already handled
+	ClaimDone
+	// ClaimInProgress means another worker holds the claim. It may have died, in which
+	// case the claim expires after dedupClaimTTL.
+	ClaimInProgress
+)
+
+// dedupRetryDelay is how long a worker waits before claiming an in-progress event again
+var dedupRetryDelay = time.Second
+
+// duplicatesDetected counts messages skipped because their event was already handled
+var duplicatesDetected atomic.Int64
+
+// DedupStore remembers the events a consumer group has handled
+type DedupStore interface {
+	// Claim reserves id for handling, unless id was already handled or another worker
+	// holds the claim
+	Claim(ctx context.Context, id string) (ClaimStatus, error)
+	// Complete records that the claimed id was handled
+	Complete(ctx context.Context, id string) error
+	// Release gives up a claim after handling failed, so a redelivery or a replay from
+	// the DLQ is handled again
+	Release(ctx context.Context, id string) error
+}
+
+// eventID returns the id a message is deduplicated by: its event-id header, or for
+// messages published without one, its position in the topic. Such messages are only
+// recognised as duplicates when Kafka redelivers the same offset, never by content.
+func eventID(msg kafka.Message) string {
+	if id := HeaderValue(msg, HeaderEventID); id != "" {
+		return id
+	}
+	return fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)
+}
+
+// RedisDedupStore keeps handled event ids in Redis for ttl. A redelivery later than
+// that is handled again.
+type RedisDedupStore struct {
+	prefix string
+	ttl    time.Duration
+}
+
+// NewRedisDedupStore creates the store of a consumer group
+func NewRedisDedupStore(groupID string, ttl time.Duration) *RedisDedupStore {
+	return &RedisDedupStore{prefix: dedupKeyPrefix + groupID + ":", ttl: ttl}
+}
+
+func (s *RedisDedupStore) Claim(ctx context.Context, id string) (ClaimStatus, error) {
+	claimed, err := redis.Rdb.SetNX(ctx, s.prefix+id, dedupProcessing, dedupClaimTTL).Result()
+	if err != nil || claimed {
+		return ClaimAcquired, err
+	}
+
+	// A claim that expires between the two calls reads as in progress and is retried
+	state, err := redis.Rdb.Get(ctx, s.prefix+id).Result()
+	if err != nil && !errors.Is(err, goredis.Nil) {
+		return ClaimInProgress, err
+	}
+	if state == dedupDone {
+		return ClaimDone, nil
+	}
+	return ClaimInProgress, nil
+}
+
+func (s *RedisDedupStore) Complete(ctx context.Context, id string) error {
+	return redis.Rdb.Set(ctx, s.prefix+id, dedupDone, s.ttl).Err()
+}
+
+func (s *RedisDedupStore) Release(ctx context.Context, id string) error {
+	return redis.Rdb.Del(ctx, s.prefix+id).Err()
+}
@@ -0,0 +1,238 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// TopicFilmEventsDLQ receives film-events messages that still fail after every retry,
+// unless DeadLetterTopic names another
+const TopicFilmEventsDLQ = TopicFilmEvents + ".dlq"
+
+// Headers added to a dead-lettered message. The original headers are kept.
+const (
+	HeaderDLQError             = "dlq-error"
+	HeaderDLQAttempts          = "dlq-attempts"
+	HeaderDLQConsumer          = "dlq-consumer"
+	HeaderDLQFailedAt          = "dlq-failed-at"
+	HeaderDLQOriginalTopic     = "dlq-original-topic"
+	HeaderDLQOriginalPartition = "dlq-original-partition"
+	HeaderDLQOriginalOffset    = "dlq-original-offset"
+	// HeaderReplayOf marks a message replayed from the DLQ with its DLQ position
+	HeaderReplayOf = "replay-of"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+	dlqReadTimeout = 5 * time.Second
+	// dlqPublishTimeout bounds the retries of publishing one dead letter
+	dlqPublishTimeout = time.Minute
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a message read back from the DLQ for inspection
+type DeadLetter struct {
+	Partition         int               `json:"partition"`
+	Offset            int64             `json:"offset"`
+	Key               string            `json:"key"`
+	Value             string            `json:"value"`
+	Headers           map[string]string `json:"headers"`
+	Error             string            `json:"error"`
+	Attempts          int               `json:"attempts"`
+	Consumer          string            `json:"consumer"`
+	OriginalTopic     string            `json:"original_topic"`
+	OriginalPartition int               `json:"original_partition"`
+	OriginalOffset    int64             `json:"original_offset"`
+	FailedAt          time.Time         `json:"failed_at"`
+}
+
+// retryBackoff returns the delay before retry attempt+1. It doubles from
+// retryBaseDelay up to retryMaxDelay, and a random half of it is jitter so consumers
+// failing together do not retry in lockstep.
+func retryBackoff(attempt int) time.Duration {
+	delay := retryMaxDelay
+	if attempt < 16 {
+		delay = min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	}
+	half := delay / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
+
+// NewDeadLetterMessage wraps a message that failed attempts times for the DLQ topic. It
+// keeps the key, value and headers, and records the error and where the message came from.
+func NewDeadLetterMessage(msg kafka.Message, topic string, consumer string, attempts int, cause error, now time.Time) kafka.Message {
+	headers := withoutDLQHeaders(msg.Headers)
+	headers = append(headers,
+		kafka.Header{Key: HeaderDLQError, Value: []byte(cause.Error())},
+		kafka.Header{Key: HeaderDLQAttempts, Value: []byte(strconv.Itoa(attempts))},
+		kafka.Header{Key: HeaderDLQConsumer, Value: []byte(consumer)},
+		kafka.Header{Key: HeaderDLQFailedAt, Value: []byte(now.UTC().Format(time.RFC3339Nano))},
+		kafka.Header{Key: HeaderDLQOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: HeaderDLQOriginalPartition, Value: []byte(strconv.Itoa(msg.Partition))},
+		kafka.Header{Key: HeaderDLQOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+
+	return kafka.Message{
+		Topic:   topic,
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	}
+}
+
+// NewReplayMessage turns a dead-lettered message back into one for the original topic
+func NewReplayMessage(msg kafka.Message) kafka.Message {
+	topic := HeaderValue(msg, HeaderDLQOriginalTopic)
+	if topic == "" {
+		topic = TopicFilmEvents
+	}
+
+	headers := withoutDLQHeaders(msg.Headers)
+	headers = append(headers, kafka.Header{
+		Key:   HeaderReplayOf,
+		Value: []byte(fmt.Sprintf("%s/%d/%d", msg.Topic, msg.Partition, msg.Offset)),
+	})
+
+	return kafka.Message{Topic: topic, Key: msg.Key, Value: msg.Value, Headers: headers}
+}
+
+// withoutDLQHeaders copies headers, dropping those added by an earlier dead-lettering or
+// replay so they never pile up
+func withoutDLQHeaders(headers []kafka.Header) []kafka.Header {
+	kept := make([]kafka.Header, 0, len(headers)+7)
+	for _, header := range headers {
+		if strings.HasPrefix(header.Key, "dlq-") || header.Key == HeaderReplayOf {
+			continue
+		}
+		kept = append(kept, header)
+	}
+	return kept
+}
+
+// publishDeadLetter writes msg to the DLQ, retrying with backoff until it succeeds or
+// ctx is done, so the original is not committed before the DLQ has it
+func publishDeadLetter(ctx context.Context, w messageWriter, msg kafka.Message) error {
+	if w == nil {
+		return ErrProducerNotInitialized
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := w.WriteMessages(ctx, msg)
+		if err == nil {
+			return nil
+		}
+		delay := retryBackoff(attempt)
+		log.Printf("❌ Failed to publish to %s, retrying in %s: %v", msg.Topic, delay, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// ReadDeadLetters returns up to limit messages of a partition of the DLQ topic from
+// offset on, and the offset to continue from. Reading does not consume them.
+func ReadDeadLetters(ctx context.Context, topic string, partition int, offset int64, limit int) ([]kafka.Message, int64, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", brokers()[0], topic, partition)
+	if err != nil {
+		return nil, offset, err
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, offset, err
+	}
+	offset = max(offset, first)
+	if offset >= last || limit <= 0 {
+		return nil, offset, nil
+	}
+
+	if _, err := conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+		return nil, offset, err
+	}
+	if err := conn.SetReadDeadline(time.Now().Add(dlqReadTimeout)); err != nil {
+		return nil, offset, err
+	}
+
+	batch := conn.ReadBatch(1, 10e6)
+	defer batch.Close()
+
+	var msgs []kafka.Message
+	next := offset
+	for len(msgs) < limit && next < last {
+		msg, err := batch.ReadMessage()
+		if err != nil {
+			return nil, offset, err
+		}
+		msg.Topic = topic
+		msgs = append(msgs, msg)
+		next = msg.Offset + 1
+	}
+	return msgs, next, nil
+}
+
+// ReplayDeadLetter publishes the message at partition/offset of the DLQ topic back onto
+// its original topic, records who replayed it in the event log and returns it. The DLQ
+// keeps its copy.
+func ReplayDeadLetter(ctx context.Context, topic string, partition int, offset int64, actor string) (kafka.Message, error) {
+	if writer == nil {
+		return kafka.Message{}, ErrProducerNotInitialized
+	}
+
+	msgs, _, err := ReadDeadLetters(ctx, topic, partition, offset, 1)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	if len(msgs) == 0 || msgs[0].Offset != offset {
+		return kafka.Message{}, ErrDeadLetterNotFound
+	}
+
+	if err := writer.WriteMessages(ctx, NewReplayMessage(msgs[0])); err != nil {
+		return kafka.Message{}, err
+	}
+
+	details, _ := json.Marshal(map[string]any{"topic": topic, "partition": partition, "offset": offset, "replayed_by": actor})
+	_ = logEvent("dlq", "kafka_messages_replayed", string(details))
+	return msgs[0], nil
+}
+
+// DescribeDeadLetter decodes the DLQ headers of msg for display
+func DescribeDeadLetter(msg kafka.Message) DeadLetter {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	attempts, _ := strconv.Atoi(headers[HeaderDLQAttempts])
+	originalPartition, _ := strconv.Atoi(headers[HeaderDLQOriginalPartition])
+	originalOffset, _ := strconv.ParseInt(headers[HeaderDLQOriginalOffset], 10, 64)
+	failedAt, _ := time.Parse(time.RFC3339Nano, headers[HeaderDLQFailedAt])
+
+	return DeadLetter{
+		Partition:         msg.Partition,
+		Offset:            msg.Offset,
+		Key:               string(msg.Key),
+		Value:             describeMessage(msg),
+		Headers:           headers,
+		Error:             headers[HeaderDLQError],
+		Attempts:          attempts,
+		Consumer:          headers[HeaderDLQConsumer],
+		OriginalTopic:     headers[HeaderDLQOriginalTopic],
+		OriginalPartition: originalPartition,
+		OriginalOffset:    originalOffset,
+		FailedAt:          failedAt,
+	}
+}
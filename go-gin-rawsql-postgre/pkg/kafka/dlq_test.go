@@ -0,0 +1,106 @@
+package kafka
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{1, retryBaseDelay},
+		{2, 2 * retryBaseDelay},
+		{3, 4 * retryBaseDelay},
+		{10, retryMaxDelay},
+		{100, retryMaxDelay},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 50; i++ {
+			delay := retryBackoff(tt.attempt)
+			assert.GreaterOrEqual(t, delay, tt.max/2, "attempt %d", tt.attempt)
+			assert.LessOrEqual(t, delay, tt.max, "attempt %d", tt.attempt)
+		}
+	}
+}
+
+func TestDeadLetterTopic(t *testing.T) {
+	t.Setenv("KAFKA_CONSUMER_TOPIC", "")
+	t.Setenv("KAFKA_CONSUMER_DLQ_TOPIC", "")
+	assert.Equal(t, TopicFilmEventsDLQ, DeadLetterTopic())
+
+	t.Setenv("KAFKA_CONSUMER_TOPIC", "films")
+	assert.Equal(t, "films.dlq", DeadLetterTopic())
+
+	t.Setenv("KAFKA_CONSUMER_DLQ_TOPIC", "films-failed")
+	assert.Equal(t, "films-failed", DeadLetterTopic())
+
+	config, err := LoadConsumerConfig("test-consumer")
+	require.NoError(t, err)
+	assert.Equal(t, "films-failed", config.DLQTopic)
+}
+
+func TestNewDeadLetterMessage(t *testing.T) {
+	original, err := NewFilmEventMessage(NewFilmEvent(FilmCreated, testFilm(), "mike"), EncodingJSON)
+	require.NoError(t, err)
+	original.Topic = TopicFilmEvents
+	original.Partition = 2
+	original.Offset = 1234
+
+	failedAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	dead := NewDeadLetterMessage(original, TopicFilmEventsDLQ, "Consumer-1", 3, errors.New("simulated failure"), failedAt)
+
+	assert.Equal(t, TopicFilmEventsDLQ, dead.Topic)
+	assert.Equal(t, original.Key, dead.Key)
+	assert.Equal(t, original.Value, dead.Value)
+	assert.Equal(t, FilmCreated, HeaderValue(dead, HeaderEventType))
+	assert.Equal(t, "simulated failure", HeaderValue(dead, HeaderDLQError))
+	assert.Equal(t, "3", HeaderValue(dead, HeaderDLQAttempts))
+	assert.Equal(t, "1234", HeaderValue(dead, HeaderDLQOriginalOffset))
+
+	dead.Partition = 0
+	dead.Offset = 7
+	letter := DescribeDeadLetter(dead)
+	assert.Equal(t, "simulated failure", letter.Error)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Equal(t, "Consumer-1", letter.Consumer)
+	assert.Equal(t, TopicFilmEvents, letter.OriginalTopic)
+	assert.Equal(t, 2, letter.OriginalPartition)
+	assert.Equal(t, int64(1234), letter.OriginalOffset)
+	assert.True(t, failedAt.Equal(letter.FailedAt))
+	assert.Contains(t, letter.Value, "Academy Dinosaur")
+}
+
+func TestNewReplayMessage(t *testing.T) {
+	original := kafka.Message{
+		Topic:     TopicFilmEvents,
+		Key:       []byte("42"),
+		Value:     []byte("payload"),
+		Headers:   []kafka.Header{{Key: HeaderEventType, Value: []byte(FilmUpdated)}},
+		Partition: 1,
+		Offset:    99,
+	}
+	dead := NewDeadLetterMessage(original, TopicFilmEventsDLQ, "Consumer-1", 3, errors.New("boom"), time.Now())
+	dead.Partition = 0
+	dead.Offset = 5
+
+	replay := NewReplayMessage(dead)
+	assert.Equal(t, TopicFilmEvents, replay.Topic)
+	assert.Equal(t, original.Key, replay.Key)
+	assert.Equal(t, original.Value, replay.Value)
+	assert.Equal(t, FilmUpdated, HeaderValue(replay, HeaderEventType))
+	assert.Equal(t, "film-events.dlq/0/5", HeaderValue(replay, HeaderReplayOf))
+	assert.Empty(t, HeaderValue(replay, HeaderDLQError))
+
+	// Failing again after a replay does not stack headers
+	again := NewDeadLetterMessage(replay, TopicFilmEventsDLQ, "Consumer-2", 3, errors.New("boom again"), time.Now())
+	assert.Len(t, again.Headers, 8)
+	assert.Equal(t, "boom again", HeaderValue(again, HeaderDLQError))
+}
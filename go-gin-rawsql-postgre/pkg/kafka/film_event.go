@@ -0,0 +1,100 @@
+package kafka
+
+import (
+	"encoding/json"
+	"errors"
+	"film-rental/internal/film/model"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Film event types
+const (
+	FilmCreated = "film.created"
+	FilmUpdated = "film.updated"
+	FilmDeleted = "film.deleted"
+)
+
+// FilmEventSchemaVersion is bumped on incompatible changes to FilmEvent. Adding fields
+// is compatible and keeps the version.
+const FilmEventSchemaVersion = 1
+
+// Supported encodings, selected with KAFKA_EVENT_ENCODING
+const (
+	EncodingJSON     = "json"
+	EncodingProtobuf = "protobuf"
+)
+
+// Content types carried in the content-type header of every message
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+)
+
+var ErrUnsupportedEncoding = errors.New("unsupported event encoding")
+
+// FilmEvent is the envelope of every message on the film-events topic
+type FilmEvent struct {
+	SchemaVersion int         `json:"schema_version"`
+	EventID       string      `json:"event_id"`
+	EventType     string      `json:"event_type"`
+	AggregateID   int64       `json:"aggregate_id"`
+	Actor         string      `json:"actor"`
+	OccurredAt    time.Time   `json:"occurred_at"`
+	Payload       *model.Film `json:"payload"`
+}
+
+// NewFilmEvent creates an event about film made by actor
+func NewFilmEvent(eventType string, film *model.Film, actor string) FilmEvent {
+	return FilmEvent{
+		SchemaVersion: FilmEventSchemaVersion,
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		AggregateID:   int64(film.ID),
+		Actor:         actor,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       film,
+	}
+}
+
+// Key is the message key. Keying by film keeps the events of a film in order on one
+// partition.
+func (e FilmEvent) Key() []byte {
+	return []byte(fmt.Sprint(e.AggregateID))
+}
+
+// eventEncoding returns the configured encoding, JSON by default
+func eventEncoding() string {
+	return envOrDefault("KAFKA_EVENT_ENCODING", EncodingJSON)
+}
+
+// EncodeFilmEvent encodes the event and returns the matching content type
+func EncodeFilmEvent(event FilmEvent, encoding string) ([]byte, string, error) {
+	switch encoding {
+	case EncodingJSON:
+		data, err := json.Marshal(event)
+		return data, ContentTypeJSON, err
+	case EncodingProtobuf:
+		data, err := marshalFilmEventProto(event)
+		return data, ContentTypeProtobuf, err
+	default:
+		return nil, "", fmt.Errorf("%w: %s", ErrUnsupportedEncoding, encoding)
+	}
+}
+
+// DecodeFilmEvent decodes a message value by its content type. Messages without one
+// are JSON.
+func DecodeFilmEvent(data []byte, contentType string) (FilmEvent, error) {
+	var event FilmEvent
+	switch contentType {
+	case ContentTypeJSON, "":
+		err := json.Unmarshal(data, &event)
+		return event, err
+	case ContentTypeProtobuf:
+		return unmarshalFilmEventProto(data)
+	default:
+		return event, fmt.Errorf("%w: %s", ErrUnsupportedEncoding, contentType)
+	}
+}
@@ -0,0 +1,97 @@
+package kafka
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative eventspb/film_event.proto
+
+import (
+	"errors"
+	"film-rental/internal/film/model"
+	"film-rental/pkg/kafka/eventspb"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Protobuf encoding of film events, converting to and from the types generated from
+// eventspb/film_event.proto. The zero time is left out of the message.
+
+var errMalformedProto = errors.New("malformed protobuf film event")
+
+func marshalFilmEventProto(e FilmEvent) ([]byte, error) {
+	msg := &eventspb.FilmEvent{
+		SchemaVersion: int32(e.SchemaVersion),
+		EventId:       e.EventID,
+		EventType:     e.EventType,
+		AggregateId:   e.AggregateID,
+		Actor:         e.Actor,
+		OccurredAt:    timestampProto(e.OccurredAt),
+	}
+	if e.Payload != nil {
+		msg.Payload = filmProto(e.Payload)
+	}
+	return proto.Marshal(msg)
+}
+
+func filmProto(f *model.Film) *eventspb.Film {
+	return &eventspb.Film{
+		FilmId:          int32(f.ID),
+		Title:           f.Title,
+		Description:     f.Description,
+		ReleaseYear:     int32(f.ReleaseYear),
+		RentalDuration:  int32(f.RentalDuration),
+		RentalRate:      f.RentalRate,
+		Length:          int32(f.Length),
+		ReplacementCost: f.ReplacementCost,
+		Rating:          f.Rating,
+		LastUpdate:      timestampProto(f.LastUpdate),
+		LanguageId:      int32(f.LanguageId),
+	}
+}
+
+func timestampProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func unmarshalFilmEventProto(data []byte) (FilmEvent, error) {
+	var msg eventspb.FilmEvent
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return FilmEvent{}, fmt.Errorf("%w: %v", errMalformedProto, err)
+	}
+
+	e := FilmEvent{
+		SchemaVersion: int(msg.GetSchemaVersion()),
+		EventID:       msg.GetEventId(),
+		EventType:     msg.GetEventType(),
+		AggregateID:   msg.GetAggregateId(),
+		Actor:         msg.GetActor(),
+		OccurredAt:    timestampTime(msg.GetOccurredAt()),
+	}
+	if p := msg.GetPayload(); p != nil {
+		e.Payload = &model.Film{
+			ID:              int(p.GetFilmId()),
+			Title:           p.GetTitle(),
+			Description:     p.GetDescription(),
+			ReleaseYear:     int(p.GetReleaseYear()),
+			RentalDuration:  int(p.GetRentalDuration()),
+			RentalRate:      p.GetRentalRate(),
+			Length:          int(p.GetLength()),
+			ReplacementCost: p.GetReplacementCost(),
+			Rating:          p.GetRating(),
+			LastUpdate:      timestampTime(p.GetLastUpdate()),
+			LanguageId:      int(p.GetLanguageId()),
+		}
+	}
+	return e, nil
+}
+
+// timestampTime returns the zero time for a missing timestamp
+func timestampTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
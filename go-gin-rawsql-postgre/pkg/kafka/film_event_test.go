@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"film-rental/internal/film/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFilm() *model.Film {
+	return &model.Film{
+		ID:              42,
+		Title:           "Academy Dinosaur",
+		Description:     "An epic drama",
+		ReleaseYear:     2006,
+		RentalDuration:  6,
+		RentalRate:      0.99,
+		Length:          86,
+		ReplacementCost: 20.99,
+		Rating:          "PG",
+		LastUpdate:      time.Date(2024, 5, 1, 10, 30, 0, 123000000, time.UTC),
+		LanguageId:      1,
+	}
+}
+
+func TestFilmEventRoundTrip(t *testing.T) {
+	event := NewFilmEvent(FilmUpdated, testFilm(), "mike")
+
+	for _, encoding := range []string{EncodingJSON, EncodingProtobuf} {
+		t.Run(encoding, func(t *testing.T) {
+			data, contentType, err := EncodeFilmEvent(event, encoding)
+			require.NoError(t, err)
+
+			decoded, err := DecodeFilmEvent(data, contentType)
+			require.NoError(t, err)
+
+			assert.Equal(t, event.SchemaVersion, decoded.SchemaVersion)
+			assert.Equal(t, event.EventID, decoded.EventID)
+			assert.Equal(t, FilmUpdated, decoded.EventType)
+			assert.Equal(t, int64(42), decoded.AggregateID)
+			assert.Equal(t, "mike", decoded.Actor)
+			assert.True(t, event.OccurredAt.Equal(decoded.OccurredAt))
+			require.NotNil(t, decoded.Payload)
+			assert.True(t, event.Payload.LastUpdate.Equal(decoded.Payload.LastUpdate))
+			decoded.Payload.LastUpdate = event.Payload.LastUpdate
+			assert.Equal(t, *event.Payload, *decoded.Payload)
+		})
+	}
+}
+
+func TestEncodeFilmEventUnsupported(t *testing.T) {
+	_, _, err := EncodeFilmEvent(NewFilmEvent(FilmCreated, testFilm(), ""), "avro")
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+
+	_, err = DecodeFilmEvent([]byte("{}"), "text/plain")
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
+
+func TestDecodeFilmEventMalformedProto(t *testing.T) {
+	_, err := DecodeFilmEvent([]byte{0x0a, 0xff}, ContentTypeProtobuf)
+	assert.Error(t, err)
+}
+
+func TestNewFilmEventMessage(t *testing.T) {
+	event := NewFilmEvent(FilmDeleted, testFilm(), "mike")
+
+	msg, err := NewFilmEventMessage(event, EncodingProtobuf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []byte("42"), msg.Key)
+	assert.Equal(t, ContentTypeProtobuf, HeaderValue(msg, HeaderContentType))
+	assert.Equal(t, event.EventID, HeaderValue(msg, HeaderEventID))
+	assert.Equal(t, FilmDeleted, HeaderValue(msg, HeaderEventType))
+	assert.Equal(t, "1", HeaderValue(msg, HeaderSchemaVersion))
+	assert.Equal(t, "", HeaderValue(msg, "missing"))
+}
+
+func TestNewRawMessage(t *testing.T) {
+	msg := newRawMessage([]byte("hello"), "text/plain", "mqtt:1")
+	assert.Equal(t, "text/plain", HeaderValue(msg, HeaderContentType))
+	assert.Equal(t, "mqtt:1", HeaderValue(msg, HeaderEventID))
+
+	msg = newRawMessage([]byte("hello"), "text/plain", "")
+	assert.Len(t, msg.Headers, 1)
+	assert.Equal(t, "", HeaderValue(msg, HeaderEventID))
+}
+
+func TestPublishRawMessageWithoutProducer(t *testing.T) {
+	err := PublishRawMessage([]byte("hello"), "text/plain", "mqtt:1")
+	assert.ErrorIs(t, err, ErrProducerNotInitialized)
+}
@@ -0,0 +1,39 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// filmConsumerService is the event log service of messages handled from film-events
+const filmConsumerService = "film-consumer"
+
+// RegisterFilmHandlers registers the handlers of the film-events topic: typed film
+// events and the raw messages bridged from MQTT, which carry no event type
+func RegisterFilmHandlers(c *Consumer) {
+	for _, eventType := range []string{FilmCreated, FilmUpdated, FilmDeleted} {
+		c.Handle(eventType, handleFilmEvent)
+	}
+	c.HandleDefault(handleRawMessage)
+}
+
+// handleFilmEvent records a film event in the event log
+func handleFilmEvent(ctx context.Context, msg kafka.Message) error {
+	event, err := DecodeFilmEvent(msg.Value, HeaderValue(msg, HeaderContentType))
+	if err != nil {
+		return fmt.Errorf("decode film event: %w", err)
+	}
+	log.Printf("Processing %s event %s for film %d", event.EventType, event.EventID, event.AggregateID)
+
+	return recordEvent(filmConsumerService, "kafka_messages_processed", describeMessage(msg))
+}
+
+// handleRawMessage records a raw message in the event log
+func handleRawMessage(ctx context.Context, msg kafka.Message) error {
+	log.Printf("Processing message: %s", string(msg.Value))
+
+	return recordEvent(filmConsumerService, "kafka_messages_processed", describeMessage(msg))
+}
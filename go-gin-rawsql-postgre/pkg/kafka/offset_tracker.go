@@ -0,0 +1,31 @@
+package kafka
+
+// offsetTracker finds how far a partition can be committed. With several workers per
+// partition, messages finish out of order, and committing an offset also commits every
+// earlier one, so only the handled prefix of the fetched offsets is committable.
+type offsetTracker struct {
+	pending []int64
+	done    map[int64]bool
+}
+
+// add records a fetched offset. Offsets are added in increasing order.
+func (t *offsetTracker) add(offset int64) {
+	t.pending = append(t.pending, offset)
+}
+
+// complete marks offset as handled and returns the highest offset that can now be
+// committed, or false when an earlier message is still being handled
+func (t *offsetTracker) complete(offset int64) (int64, bool) {
+	if t.done == nil {
+		t.done = make(map[int64]bool)
+	}
+	t.done[offset] = true
+
+	committable, ok := int64(0), false
+	for len(t.pending) > 0 && t.done[t.pending[0]] {
+		committable, ok = t.pending[0], true
+		delete(t.done, t.pending[0])
+		t.pending = t.pending[1:]
+	}
+	return committable, ok
+}
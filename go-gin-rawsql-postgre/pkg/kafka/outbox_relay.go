@@ -0,0 +1,139 @@
+package kafka
+
+import (
+	"context"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"film-rental/pkg/outbox"
+	"fmt"
+	"io"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+const (
+	outboxBatchSize     = 100
+	outboxPollInterval  = time.Second
+	outboxMaxBackoff    = time.Minute
+	outboxRetention     = 7 * 24 * time.Hour
+	outboxPruneInterval = time.Hour
+)
+
+// Relay counters reported by the metrics server
+var (
+	outboxPublished atomic.Int64
+	outboxFailures  atomic.Int64
+)
+
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// StartOutboxRelay publishes pending outbox messages until ctx is cancelled. A failed
+// batch is retried with a doubling delay; later messages wait so that the events of a
+// film or staff member are never published out of order.
+func StartOutboxRelay(ctx context.Context) {
+	if writer == nil {
+		log.Printf("❌ Outbox relay not started: %v", ErrProducerNotInitialized)
+		return
+	}
+
+	log.Println("Starting outbox relay...")
+
+	backoff := outboxPollInterval
+	lastPrune := time.Time{}
+	for {
+		wait := outboxPollInterval
+		published, err := relayOutboxBatch(ctx, writer, outboxBatchSize)
+		switch {
+		case err != nil:
+			log.Printf("❌ Failed to relay outbox messages, retrying in %s: %v", backoff, err)
+			wait = backoff
+			backoff = min(backoff*2, outboxMaxBackoff)
+		case published == outboxBatchSize:
+			// More may be waiting
+			wait = 0
+			backoff = outboxPollInterval
+		default:
+			backoff = outboxPollInterval
+		}
+
+		if time.Since(lastPrune) > outboxPruneInterval {
+			lastPrune = time.Now()
+			if _, err := outbox.DeleteSentBefore(lastPrune.Add(-outboxRetention)); err != nil {
+				log.Printf("❌ Failed to prune outbox: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// relayOutboxBatch publishes the oldest pending messages and marks them sent. The
+// messages are sent again if the commit fails after publishing, so delivery is at
+// least once.
+func relayOutboxBatch(ctx context.Context, w messageWriter, limit int) (int, error) {
+	tx, err := dbRaw.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	locked, err := outbox.TryLock(tx)
+	if err != nil || !locked {
+		// Another instance is relaying
+		return 0, err
+	}
+
+	pending, err := outbox.FetchPending(tx, limit)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	ids := make([]int64, len(pending))
+	msgs := make([]kafka.Message, len(pending))
+	for i, p := range pending {
+		ids[i] = p.ID
+		msgs[i] = kafkaMessage(p)
+	}
+
+	if err := w.WriteMessages(ctx, msgs...); err != nil {
+		outboxFailures.Add(1)
+		if markErr := outbox.MarkFailed(tx, ids, err.Error()); markErr != nil {
+			return 0, markErr
+		}
+		if commitErr := tx.Commit(); commitErr != nil {
+			return 0, commitErr
+		}
+		return 0, err
+	}
+
+	if err := outbox.MarkSent(tx, ids, time.Now()); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	outboxPublished.Add(int64(len(pending)))
+	return len(pending), nil
+}
+
+// writeOutboxMetrics reports the outbox backlog and relay counters
+func writeOutboxMetrics(w io.Writer) {
+	stats, err := outbox.GetStats(time.Now())
+	if err != nil {
+		log.Printf("Error reading outbox stats: %v", err)
+	} else {
+		fmt.Fprintf(w, "outbox_pending_messages %d\n", stats.Pending)
+		fmt.Fprintf(w, "outbox_lag_seconds %.3f\n", stats.Lag.Seconds())
+	}
+	fmt.Fprintf(w, "outbox_published_total %d\n", outboxPublished.Load())
+	fmt.Fprintf(w, "outbox_publish_failures_total %d\n", outboxFailures.Load())
+}
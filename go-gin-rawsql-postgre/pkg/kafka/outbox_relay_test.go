@@ -0,0 +1,93 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeWriter struct {
+	written []kafka.Message
+	err     error
+}
+
+func (f *fakeWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.written = append(f.written, msgs...)
+	return nil
+}
+
+func setupOutboxMock(t *testing.T) sqlmock.Sqlmock {
+	mockDB, mock, err := sqlmock.New()
+	require.NoError(t, err)
+	t.Cleanup(func() { mockDB.Close() })
+	dbRaw.DB = mockDB
+	return mock
+}
+
+func pendingRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "topic", "message_key", "value", "headers", "created_at", "attempts"}).
+		AddRow(1, TopicFilmEvents, []byte("42"), []byte(`{"event_type":"film.created"}`), `{"event-type":"film.created","content-type":"application/json"}`, time.Now(), 0).
+		AddRow(2, TopicStaffEvents, []byte("7"), []byte(`{"event_type":"staff.updated"}`), `{"event-type":"staff.updated"}`, time.Now(), 2)
+}
+
+func TestRelayOutboxBatch(t *testing.T) {
+	mock := setupOutboxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`SELECT (.+) FROM outbox_messages WHERE sent_at IS NULL`).WithArgs(10).WillReturnRows(pendingRows())
+	mock.ExpectExec(`UPDATE outbox_messages SET sent_at`).WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	w := &fakeWriter{}
+	published, err := relayOutboxBatch(context.Background(), w, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, published)
+
+	require.Len(t, w.written, 2)
+	assert.Equal(t, TopicFilmEvents, w.written[0].Topic)
+	assert.Equal(t, []byte("42"), w.written[0].Key)
+	assert.Equal(t, "film.created", HeaderValue(w.written[0], HeaderEventType))
+	assert.Equal(t, ContentTypeJSON, HeaderValue(w.written[0], HeaderContentType))
+	assert.Equal(t, TopicStaffEvents, w.written[1].Topic)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayOutboxBatchWriteFailure(t *testing.T) {
+	mock := setupOutboxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(true))
+	mock.ExpectQuery(`SELECT (.+) FROM outbox_messages WHERE sent_at IS NULL`).WithArgs(10).WillReturnRows(pendingRows())
+	mock.ExpectExec(`UPDATE outbox_messages SET attempts = attempts \+ 1, last_error`).
+		WithArgs("broker unavailable", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	published, err := relayOutboxBatch(context.Background(), &fakeWriter{err: errors.New("broker unavailable")}, 10)
+	assert.EqualError(t, err, "broker unavailable")
+	assert.Equal(t, 0, published)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRelayOutboxBatchLockedElsewhere(t *testing.T) {
+	mock := setupOutboxMock(t)
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT pg_try_advisory_xact_lock`).WillReturnRows(sqlmock.NewRows([]string{"locked"}).AddRow(false))
+	mock.ExpectRollback()
+
+	w := &fakeWriter{}
+	published, err := relayOutboxBatch(context.Background(), w, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 0, published)
+	assert.Empty(t, w.written)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -2,26 +2,124 @@ package kafka
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"film-rental/pkg/outbox"
+	"fmt"
+	"sort"
+	"time"
 
 	"github.com/segmentio/kafka-go"
 )
 
-const TopicFilmEvents = "film-events"
+const (
+	TopicFilmEvents  = "film-events"
+	TopicStaffEvents = "staff-events"
+)
+
+// Message headers set on every film and staff event
+const (
+	HeaderContentType   = "content-type"
+	HeaderEventType     = "event-type"
+	HeaderSchemaVersion = "schema-version"
+)
+
+var ErrProducerNotInitialized = errors.New("kafka producer is not initialized")
 
-var filmWriter *kafka.Writer
+// producerBatchTimeout caps how long a write waits for more messages to batch with.
+// Writes are synchronous, so kafka-go's default of a second would limit a caller
+// forwarding one message at a time, like the MQTT bridge, to a message a second.
+const producerBatchTimeout = 10 * time.Millisecond
+
+var writer *kafka.Writer
 
 func InitKafkaProducer() {
-	filmWriter = kafka.NewWriter(kafka.WriterConfig{
-		Brokers:  []string{"localhost:9092"},
-		Topic:    TopicFilmEvents,
-		Balancer: &kafka.LeastBytes{}, // or RoundRobin
+	writer = kafka.NewWriter(kafka.WriterConfig{
+		Brokers: brokers(),
+		// Hashing the key sends every event of a film or staff member to the same partition
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: producerBatchTimeout,
 	})
 }
 
-func PublishFilmEvent(message string) error {
+// EnqueueFilmEvent encodes the event with the configured encoding and adds it to the
+// outbox in tx, keyed by film_id. The outbox relay publishes it once tx commits.
+func EnqueueFilmEvent(tx *sql.Tx, event FilmEvent) error {
+	msg, err := NewFilmEventMessage(event, eventEncoding())
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(tx, outboxMessage(TopicFilmEvents, msg), event.OccurredAt)
+}
+
+// PublishRawMessage forwards an opaque payload, such as one bridged from MQTT, to the
+// film-events topic. It has no key, so raw messages are spread over the partitions.
+// Consumers deduplicate by eventID, so a payload delivered twice must keep its id; an
+// empty eventID publishes the message without one.
+func PublishRawMessage(value []byte, contentType string, eventID string) error {
+	if writer == nil {
+		return ErrProducerNotInitialized
+	}
+
+	return writer.WriteMessages(context.Background(), newRawMessage(value, contentType, eventID))
+}
+
+func newRawMessage(value []byte, contentType string, eventID string) kafka.Message {
 	msg := kafka.Message{
-		Key:   []byte("film-key"),
-		Value: []byte(message),
+		Topic:   TopicFilmEvents,
+		Value:   value,
+		Headers: []kafka.Header{{Key: HeaderContentType, Value: []byte(contentType)}},
+	}
+	if eventID != "" {
+		msg.Headers = append(msg.Headers, kafka.Header{Key: HeaderEventID, Value: []byte(eventID)})
+	}
+	return msg
+}
+
+// NewFilmEventMessage builds the Kafka message of an event
+func NewFilmEventMessage(event FilmEvent, encoding string) (kafka.Message, error) {
+	value, contentType, err := EncodeFilmEvent(event, encoding)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return kafka.Message{
+		Key:   event.Key(),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(contentType)},
+			{Key: HeaderEventID, Value: []byte(event.EventID)},
+			{Key: HeaderEventType, Value: []byte(event.EventType)},
+			{Key: HeaderSchemaVersion, Value: []byte(fmt.Sprint(event.SchemaVersion))},
+		},
+	}, nil
+}
+
+// outboxMessage converts a message for storage in the outbox
+func outboxMessage(topic string, msg kafka.Message) outbox.Message {
+	headers := make(map[string]string, len(msg.Headers))
+	for _, header := range msg.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return outbox.Message{Topic: topic, Key: msg.Key, Value: msg.Value, Headers: headers}
+}
+
+// kafkaMessage converts a pending outbox message back for publishing
+func kafkaMessage(p outbox.Pending) kafka.Message {
+	headers := make([]kafka.Header, 0, len(p.Headers))
+	for key, value := range p.Headers {
+		headers = append(headers, kafka.Header{Key: key, Value: []byte(value)})
+	}
+	sort.Slice(headers, func(i, j int) bool { return headers[i].Key < headers[j].Key })
+	return kafka.Message{Topic: p.Topic, Key: p.Key, Value: p.Value, Headers: headers}
+}
+
+// HeaderValue returns the value of the first header named key, or ""
+func HeaderValue(msg kafka.Message, key string) string {
+	for _, header := range msg.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
 	}
-	return filmWriter.WriteMessages(context.Background(), msg)
+	return ""
 }
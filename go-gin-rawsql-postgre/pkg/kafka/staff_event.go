@@ -0,0 +1,79 @@
+package kafka
+
+import (
+	"database/sql"
+	"encoding/json"
+	"film-rental/internal/staff/model"
+	"film-rental/pkg/outbox"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+)
+
+// Staff event types
+const (
+	StaffCreated     = "staff.created"
+	StaffUpdated     = "staff.updated"
+	StaffActivated   = "staff.activated"
+	StaffDeactivated = "staff.deactivated"
+	StaffDeleted     = "staff.deleted"
+)
+
+// StaffEventSchemaVersion is bumped on incompatible changes to StaffEvent
+const StaffEventSchemaVersion = 1
+
+// StaffEvent is the envelope of every message on the staff-events topic. Staff events
+// are always JSON; the payload never carries the password hash or picture.
+type StaffEvent struct {
+	SchemaVersion int          `json:"schema_version"`
+	EventID       string       `json:"event_id"`
+	EventType     string       `json:"event_type"`
+	AggregateID   int64        `json:"aggregate_id"`
+	Actor         string       `json:"actor"`
+	OccurredAt    time.Time    `json:"occurred_at"`
+	Payload       *model.Staff `json:"payload"`
+}
+
+// NewStaffEvent creates an event about staff made by actor
+func NewStaffEvent(eventType string, staff *model.Staff, actor string) StaffEvent {
+	return StaffEvent{
+		SchemaVersion: StaffEventSchemaVersion,
+		EventID:       uuid.NewString(),
+		EventType:     eventType,
+		AggregateID:   int64(staff.StaffId),
+		Actor:         actor,
+		OccurredAt:    time.Now().UTC(),
+		Payload:       staff,
+	}
+}
+
+// NewStaffEventMessage builds the Kafka message of an event, keyed by staff_id
+func NewStaffEventMessage(event StaffEvent) (kafka.Message, error) {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+
+	return kafka.Message{
+		Key:   []byte(fmt.Sprint(event.AggregateID)),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: HeaderContentType, Value: []byte(ContentTypeJSON)},
+			{Key: HeaderEventID, Value: []byte(event.EventID)},
+			{Key: HeaderEventType, Value: []byte(event.EventType)},
+			{Key: HeaderSchemaVersion, Value: []byte(fmt.Sprint(event.SchemaVersion))},
+		},
+	}, nil
+}
+
+// EnqueueStaffEvent adds the event to the outbox in tx. The outbox relay publishes it
+// once tx commits.
+func EnqueueStaffEvent(tx *sql.Tx, event StaffEvent) error {
+	msg, err := NewStaffEventMessage(event)
+	if err != nil {
+		return err
+	}
+	return outbox.Enqueue(tx, outboxMessage(TopicStaffEvents, msg), event.OccurredAt)
+}
@@ -0,0 +1,29 @@
+package mailer
+
+import "sync"
+
+// FakeSender keeps messages in memory instead of sending them, for tests
+type FakeSender struct {
+	mu       sync.Mutex
+	messages []Message
+	Err      error
+}
+
+func (f *FakeSender) Send(msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far
+func (f *FakeSender) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Message(nil), f.messages...)
+}
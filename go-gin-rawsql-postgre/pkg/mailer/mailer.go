@@ -0,0 +1,81 @@
+// Package mailer renders templated emails and hands them to a Sender, SMTP in
+// production and an in-memory fake in tests.
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFiles embed.FS
+
+// Message is a plain text email
+type Message struct {
+	To      []string
+	Subject string
+	Body    string
+}
+
+// Sender delivers a message
+type Sender interface {
+	Send(msg Message) error
+}
+
+// Mailer renders the named templates under templates/. Each template defines a
+// "subject" and a "body" block.
+type Mailer struct {
+	sender    Sender
+	templates map[string]*template.Template
+}
+
+// Default is the mailer used by handlers; it is set up in main
+var Default *Mailer
+
+var ErrUnknownTemplate = errors.New("unknown email template")
+
+// New creates a mailer sending through sender with the embedded templates
+func New(sender Sender) (*Mailer, error) {
+	entries, err := templateFiles.ReadDir("templates")
+	if err != nil {
+		return nil, err
+	}
+
+	templates := make(map[string]*template.Template, len(entries))
+	for _, entry := range entries {
+		name := strings.TrimSuffix(entry.Name(), ".tmpl")
+		tmpl, err := template.ParseFS(templateFiles, "templates/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("parse email template %s: %w", name, err)
+		}
+		templates[name] = tmpl
+	}
+
+	return &Mailer{sender: sender, templates: templates}, nil
+}
+
+// Send renders the template with data and sends the result to the recipients
+func (m *Mailer) Send(templateName string, to []string, data any) error {
+	tmpl, ok := m.templates[templateName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownTemplate, templateName)
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("render %s subject: %w", templateName, err)
+	}
+	if err := tmpl.ExecuteTemplate(&body, "body", data); err != nil {
+		return fmt.Errorf("render %s body: %w", templateName, err)
+	}
+
+	return m.sender.Send(Message{
+		To:      to,
+		Subject: strings.TrimSpace(subject.String()),
+		Body:    strings.TrimSpace(body.String()) + "\n",
+	})
+}
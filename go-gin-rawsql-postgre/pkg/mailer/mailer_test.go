@@ -0,0 +1,56 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMailerSend(t *testing.T) {
+	sender := &FakeSender{}
+	m, err := New(sender)
+	require.NoError(t, err)
+
+	err = m.Send("password_reset", []string{"mike@example.com"}, map[string]any{
+		"FirstName":        "Mike",
+		"Username":         "mike",
+		"ResetURL":         "https://example.com/reset?token=abc",
+		"ExpiresInMinutes": 30,
+	})
+	require.NoError(t, err)
+
+	messages := sender.Messages()
+	require.Len(t, messages, 1)
+	assert.Equal(t, []string{"mike@example.com"}, messages[0].To)
+	assert.Equal(t, "Reset your Film Rental password", messages[0].Subject)
+	assert.Contains(t, messages[0].Body, "Hello Mike,")
+	assert.Contains(t, messages[0].Body, "https://example.com/reset?token=abc")
+	assert.Contains(t, messages[0].Body, "within 30 minutes")
+}
+
+func TestMailerSend_UnknownTemplate(t *testing.T) {
+	m, err := New(&FakeSender{})
+	require.NoError(t, err)
+
+	err = m.Send("missing", []string{"mike@example.com"}, nil)
+	assert.ErrorIs(t, err, ErrUnknownTemplate)
+}
+
+func TestBuildMessage_HeaderInjection(t *testing.T) {
+	msg := Message{
+		To:      []string{"mike@example.com"},
+		Subject: "Hello\r\nBcc: victim@example.com",
+		Body:    "line one\nline two\n",
+	}
+
+	raw := string(buildMessage("noreply@example.com", msg, time.Unix(0, 0)))
+	headers, body, found := strings.Cut(raw, "\r\n\r\n")
+	require.True(t, found)
+
+	assert.NotContains(t, headers, "\r\nBcc:")
+	assert.Contains(t, headers, "Subject: Hello  Bcc: victim@example.com")
+	assert.Equal(t, "line one\r\nline two\r\n", body)
+}
@@ -0,0 +1,69 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+)
+
+// SMTPSender sends mail through an SMTP server with PLAIN auth over STARTTLS
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// NewSMTPSenderFromEnv reads SMTP_HOST and SMTP_PORT (Gmail by default), SENDER_EMAIL
+// and GMAIL_APP_PASSWORD
+func NewSMTPSenderFromEnv() *SMTPSender {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		host = "smtp.gmail.com"
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	from := os.Getenv("SENDER_EMAIL")
+
+	return &SMTPSender{
+		Host:     host,
+		Port:     port,
+		Username: from,
+		Password: os.Getenv("GMAIL_APP_PASSWORD"),
+		From:     from,
+	}
+}
+
+func (s *SMTPSender) Send(msg Message) error {
+	auth := smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	err := smtp.SendMail(net.JoinHostPort(s.Host, s.Port), auth, s.From, msg.To, buildMessage(s.From, msg, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// buildMessage formats the RFC 5322 message. Header values have line breaks removed so
+// user supplied text cannot inject headers.
+func buildMessage(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + headerValue(from) + "\r\n")
+	b.WriteString("To: " + headerValue(strings.Join(msg.To, ", ")) + "\r\n")
+	b.WriteString("Subject: " + headerValue(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(b.String())
+}
+
+func headerValue(value string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(value)
+}
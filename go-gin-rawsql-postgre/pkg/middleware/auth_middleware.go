@@ -16,8 +16,26 @@ const (
 	authorizationPayloadKey = "authorization_payload"
 )
 
+var revocationChecker token.RevocationChecker
+
+// SetRevocationChecker configures how AuthMiddleware checks whether a valid token has
+// been revoked. Without a checker only the signature and expiry are verified.
+func SetRevocationChecker(checker token.RevocationChecker) {
+	revocationChecker = checker
+}
+
 // AuthMiddleware creates a gin middleware for authorization
-func AuthMiddleware(tokenMaker *token.JWTMaker) gin.HandlerFunc {
+func AuthMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+	return authenticate(tokenMaker, token.TokenTypeAccessToken)
+}
+
+// MFAPendingMiddleware authenticates the second step of a login with the token issued
+// after the password was verified. Access tokens are not accepted.
+func MFAPendingMiddleware(tokenMaker token.Maker) gin.HandlerFunc {
+	return authenticate(tokenMaker, token.TokenTypeMFAPendingToken)
+}
+
+func authenticate(tokenMaker token.Maker, tokenType token.TokenType) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authorizationHeader := ctx.GetHeader(authorizationHeaderKey)
 
@@ -42,13 +60,44 @@ func AuthMiddleware(tokenMaker *token.JWTMaker) gin.HandlerFunc {
 		}
 
 		accessToken := fields[1]
-		payload, err := tokenMaker.VerifyToken(accessToken, token.TokenTypeAccessToken)
+		payload, err := tokenMaker.VerifyToken(accessToken, tokenType)
 		if err != nil {
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 			return
 		}
 
+		if revocationChecker != nil {
+			revoked, err := revocationChecker.IsRevoked(payload)
+			if err != nil {
+				// Fail closed: a token we cannot check is not trusted
+				ctx.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "unable to verify token revocation"})
+				return
+			}
+			if revoked {
+				ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "token has been revoked"})
+				return
+			}
+		}
+
 		ctx.Set(authorizationPayloadKey, payload)
 		ctx.Next()
 	}
 }
+
+// GetAuthPayload returns the token payload stored by AuthMiddleware
+func GetAuthPayload(ctx *gin.Context) (*token.Payload, bool) {
+	payloadInterface, exists := ctx.Get(authorizationPayloadKey)
+	if !exists {
+		return nil, false
+	}
+	payload, ok := payloadInterface.(*token.Payload)
+	return payload, ok
+}
+
+// AuthUsername returns the username of the authenticated caller, or "" without one
+func AuthUsername(ctx *gin.Context) string {
+	if payload, ok := GetAuthPayload(ctx); ok {
+		return payload.Username
+	}
+	return ""
+}
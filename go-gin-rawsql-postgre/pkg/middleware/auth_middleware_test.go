@@ -177,3 +177,97 @@ func TestAuthMiddlewareWithPayload(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "testuser")
 	assert.Contains(t, w.Body.String(), "admin")
 }
+
+type fakeRevocationChecker struct {
+	revokedBefore time.Time
+	err           error
+}
+
+func (f fakeRevocationChecker) IsRevoked(payload *token.Payload) (bool, error) {
+	return payload.IssuedAt.Before(f.revokedBefore), f.err
+}
+
+func TestAuthMiddlewareRevokedToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtMaker, err := token.NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	oldToken, err := jwtMaker.CreateToken("testuser", "admin", time.Hour, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+	watermark := time.Now()
+	newToken, err := jwtMaker.CreateToken("testuser", "admin", time.Hour, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	defer SetRevocationChecker(nil)
+
+	tests := []struct {
+		name           string
+		checker        fakeRevocationChecker
+		accessToken    string
+		expectedStatus int
+	}{
+		{"Token issued before watermark", fakeRevocationChecker{revokedBefore: watermark}, oldToken, http.StatusUnauthorized},
+		{"Token issued after watermark", fakeRevocationChecker{revokedBefore: watermark}, newToken, http.StatusOK},
+		{"Checker unavailable", fakeRevocationChecker{err: assert.AnError}, newToken, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetRevocationChecker(tt.checker)
+
+			router := gin.New()
+			router.GET("/test", AuthMiddleware(jwtMaker), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeaderKey, "Bearer "+tt.accessToken)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestMFAPendingMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtMaker, err := token.NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	mfaToken, err := jwtMaker.CreateToken("testuser", "admin", time.Minute, token.TokenTypeMFAPendingToken)
+	require.NoError(t, err)
+	accessToken, err := jwtMaker.CreateToken("testuser", "admin", time.Hour, token.TokenTypeAccessToken)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		middleware     gin.HandlerFunc
+		token          string
+		expectedStatus int
+	}{
+		{"MFA token on MFA route", MFAPendingMiddleware(jwtMaker), mfaToken, http.StatusOK},
+		{"Access token on MFA route", MFAPendingMiddleware(jwtMaker), accessToken, http.StatusUnauthorized},
+		{"MFA token on protected route", AuthMiddleware(jwtMaker), mfaToken, http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/test", tt.middleware, func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeaderKey, "Bearer "+tt.token)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -3,24 +3,23 @@ package middleware
 import (
 	token "film-rental/internal/token"
 	"film-rental/internal/token/model"
+	"film-rental/pkg/scope"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
+const ownerAccessKey = "owner_access"
+
+// OwnerFunc reports whether the authenticated user owns the resource the request
+// addresses, e.g. their own staff profile
+type OwnerFunc func(c *gin.Context, payload *token.Payload) (bool, error)
+
 // RequirePermission creates a middleware that checks if the user has a specific permission
 func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Get the payload from the auth middleware
-		payloadInterface, exists := c.Get(authorizationPayloadKey)
-		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization payload not found"})
-			return
-		}
-
-		payload, ok := payloadInterface.(*token.Payload)
+		payload, ok := requirePayload(c)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid authorization payload"})
 			return
 		}
 
@@ -38,3 +37,127 @@ func RequirePermission(permission string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireAll creates a middleware that checks if the user has every one of the permissions
+func RequireAll(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := requirePayload(c)
+		if !ok {
+			return
+		}
+
+		for _, permission := range permissions {
+			if !model.HasPermission(payload.Role, permission) {
+				abortInsufficientPermissions(c, payload, "all", permissions)
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireAny creates a middleware that checks if the user has at least one of the permissions
+func RequireAny(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := requirePayload(c)
+		if !ok {
+			return
+		}
+
+		for _, permission := range permissions {
+			if model.HasPermission(payload.Role, permission) {
+				c.Next()
+				return
+			}
+		}
+
+		abortInsufficientPermissions(c, payload, "any", permissions)
+	}
+}
+
+// RequirePermissionOrOwner lets the request through when the user has the permission or
+// owns the addressed resource. Handlers can tell the two apart with IsOwnerAccess, e.g.
+// to stop staff from changing their own role.
+func RequirePermissionOrOwner(permission string, isOwner OwnerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		payload, ok := requirePayload(c)
+		if !ok {
+			return
+		}
+
+		if model.HasPermission(payload.Role, permission) {
+			c.Next()
+			return
+		}
+
+		owner, err := isOwner(c, payload)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to check resource ownership"})
+			return
+		}
+		if !owner {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error":               "Insufficient permissions",
+				"message":             "You can only perform this action on your own resources",
+				"required_permission": permission,
+				"user_role":           payload.Role,
+			})
+			return
+		}
+
+		c.Set(ownerAccessKey, true)
+		c.Next()
+	}
+}
+
+// IsOwnerAccess reports whether RequirePermissionOrOwner admitted the request only
+// because the user owns the resource
+func IsOwnerAccess(c *gin.Context) bool {
+	return c.GetBool(ownerAccessKey)
+}
+
+// requirePayload gets the payload from the auth middleware, aborting the request when
+// it is missing
+func requirePayload(c *gin.Context) (*token.Payload, bool) {
+	payloadInterface, exists := c.Get(authorizationPayloadKey)
+	if !exists {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization payload not found"})
+		return nil, false
+	}
+
+	payload, ok := payloadInterface.(*token.Payload)
+	if !ok {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Invalid authorization payload"})
+		return nil, false
+	}
+
+	return payload, true
+}
+
+func abortInsufficientPermissions(c *gin.Context, payload *token.Payload, mode string, permissions []string) {
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+		"error":                "Insufficient permissions",
+		"message":              "You don't have permission to perform this action",
+		"required_permissions": permissions,
+		"require":              mode,
+		"user_role":            payload.Role,
+	})
+}
+
+// StoreScope returns the store the authenticated user is restricted to, or
+// scope.AllStores when their role has the store:all permission
+func StoreScope(c *gin.Context) int {
+	payload, ok := GetAuthPayload(c)
+	if !ok {
+		return scope.NoStore
+	}
+	if model.HasPermission(payload.Role, model.PermissionStoreAll) {
+		return scope.AllStores
+	}
+	if payload.StoreId <= 0 {
+		// Tokens issued before store scoping carry no store
+		return scope.NoStore
+	}
+	return payload.StoreId
+}
@@ -3,8 +3,10 @@ package middleware
 import (
 	"film-rental/internal/token"
 	"film-rental/internal/token/model"
+	"film-rental/pkg/scope"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -266,3 +268,119 @@ func TestRequirePermissionWithAllPermissions(t *testing.T) {
 		})
 	}
 }
+
+func TestStoreScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		role     string
+		storeId  int
+		expected int
+	}{
+		{"Admin sees every store", model.RoleAdmin, 1, scope.AllStores},
+		{"User limited to own store", model.RoleUser, 2, 2},
+		{"User without store sees nothing", model.RoleUser, 0, scope.NoStore},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			payload, err := token.NewPayload("testuser", tt.role, time.Hour, token.TokenTypeAccessToken)
+			require.NoError(t, err)
+			payload.StoreId = tt.storeId
+			c.Set(authorizationPayloadKey, payload)
+
+			assert.Equal(t, tt.expected, StoreScope(c))
+		})
+	}
+}
+
+func TestRequireAllAndAny(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtMaker, err := token.NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		role           string
+		middleware     gin.HandlerFunc
+		expectedStatus int
+	}{
+		{"Admin has all", model.RoleAdmin, RequireAll(model.PermissionFilmCreate, model.PermissionStaffCreate), http.StatusOK},
+		{"User lacks one of all", model.RoleUser, RequireAll(model.PermissionFilmRead, model.PermissionFilmCreate), http.StatusForbidden},
+		{"User has one of any", model.RoleUser, RequireAny(model.PermissionFilmCreate, model.PermissionFilmRead), http.StatusOK},
+		{"User has none of any", model.RoleUser, RequireAny(model.PermissionFilmCreate, model.PermissionFilmDelete), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessToken, err := jwtMaker.CreateToken("testuser", tt.role, time.Hour, token.TokenTypeAccessToken)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.GET("/test", AuthMiddleware(jwtMaker), tt.middleware, func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"message": "success"})
+			})
+
+			req, err := http.NewRequest("GET", "/test", nil)
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, w.Body.String(), "required_permissions")
+			}
+		})
+	}
+}
+
+func TestRequirePermissionOrOwner(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtMaker, err := token.NewJWTMaker("12345678901234567890123456789012")
+	require.NoError(t, err)
+
+	// The user owns the profile whose :username matches their own
+	isOwner := func(c *gin.Context, payload *token.Payload) (bool, error) {
+		return c.Param("username") == payload.Username, nil
+	}
+
+	tests := []struct {
+		name           string
+		role           string
+		path           string
+		expectedStatus int
+		expectedOwner  bool
+	}{
+		{"Admin edits another profile", model.RoleAdmin, "/staff/other", http.StatusOK, false},
+		{"User edits own profile", model.RoleUser, "/staff/testuser", http.StatusOK, true},
+		{"User edits another profile", model.RoleUser, "/staff/other", http.StatusForbidden, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accessToken, err := jwtMaker.CreateToken("testuser", tt.role, time.Hour, token.TokenTypeAccessToken)
+			require.NoError(t, err)
+
+			router := gin.New()
+			router.PUT("/staff/:username", AuthMiddleware(jwtMaker), RequirePermissionOrOwner(model.PermissionStaffUpdate, isOwner), func(c *gin.Context) {
+				c.JSON(http.StatusOK, gin.H{"owner": IsOwnerAccess(c)})
+			})
+
+			req, err := http.NewRequest("PUT", tt.path, nil)
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeaderKey, "Bearer "+accessToken)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), strconv.FormatBool(tt.expectedOwner))
+			}
+		})
+	}
+}
@@ -1,28 +1,19 @@
 package monitoring
 
 import (
-	"fmt"
-	"net/smtp"
+	"errors"
+	"film-rental/pkg/mailer"
 	"os"
 )
 
+// SendEmailAlert mails an operational alert to RECEIVER_EMAIL
 func SendEmailAlert(subject, body string) error {
-    from :=  os.Getenv("SENDER_EMAIL")
-    to := os.Getenv("RECEIVER_EMAIL")
-    password := os.Getenv("GMAIL_APP_PASSWORD")
+	if mailer.Default == nil {
+		return errors.New("failed to send email: mailer is not configured")
+	}
 
-    msg := "From: " + from + "\n" +
-        "To: " + to + "\n" +
-        "Subject: " + subject + "\n\n" +
-        body
-
-    // Gmail SMTP server
-    err := smtp.SendMail("smtp.gmail.com:587",
-        smtp.PlainAuth("", from, password, "smtp.gmail.com"),
-        from, []string{to}, []byte(msg))
-
-    if err != nil {
-        return fmt.Errorf("failed to send email: %w", err)
-    }
-    return nil
-}
\ No newline at end of file
+	return mailer.Default.Send("alert", []string{os.Getenv("RECEIVER_EMAIL")}, map[string]string{
+		"Subject": subject,
+		"Body":    body,
+	})
+}
@@ -1,6 +1,7 @@
 package mqtt
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -19,12 +20,16 @@ func StartMQTTSubscriber() {
 		SetDefaultPublishHandler(func(client mqtt.Client, msg mqtt.Message) {
 			log.Printf("Received MQTT message: topic=%s payload=%s", msg.Topic(), msg.Payload())
 
-			err := kafka.PublishFilmEvent(string(msg.Payload()))
+			err := kafka.PublishRawMessage(msg.Payload(), "text/plain", messageID(msg.Payload()))
 			if err != nil {
 				monitoring.SendEmailAlert("Failed to publish to Kafka", fmt.Sprintf("Failed to publish to Kafka: %v", err))
 				log.Printf("Failed to publish to Kafka: %v", err)
+				return
 			}
-		}).SetCleanSession(false)
+			// Acked only once Kafka has it, so the broker redelivers what was not forwarded
+			msg.Ack()
+		}).SetCleanSession(false).
+		SetAutoAckDisabled(true)
 
 	client := mqtt.NewClient(opts)
 	if token := client.Connect(); token.Wait() && token.Error() != nil {
@@ -39,4 +44,18 @@ func StartMQTTSubscriber() {
 	}
 
 	log.Printf("MQTT subscriber is listening on topic %s", topic)
-}    
+}
+
+// messageID returns the event id of an MQTT message: the "id" its publisher put in a JSON
+// payload, which a QoS 1 redelivery repeats. MQTT 3.1.1 has no user properties to carry
+// it in. Other messages get no id, so two of them with the same content are both
+// processed.
+func messageID(payload []byte) string {
+	var envelope struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil || envelope.ID == "" {
+		return ""
+	}
+	return "mqtt:" + envelope.ID
+}
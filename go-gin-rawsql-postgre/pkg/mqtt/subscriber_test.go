@@ -0,0 +1,16 @@
+package mqtt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageID(t *testing.T) {
+	assert.Equal(t, "mqtt:run-1-42", messageID([]byte(`{"id":"run-1-42","message":"test message 42"}`)))
+
+	// Without a producer supplied id the message is not deduplicated by content
+	assert.Equal(t, "", messageID([]byte("test message 42")))
+	assert.Equal(t, "", messageID([]byte(`{"message":"test message 42"}`)))
+	assert.Equal(t, "", messageID([]byte(`{"id":42}`)))
+}
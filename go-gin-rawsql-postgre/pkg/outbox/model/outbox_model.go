@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// OutboxMessage is a Kafka message written in the same transaction as the change it
+// describes. The relay publishes pending messages in ID order and sets SentAt.
+type OutboxMessage struct {
+	ID         int64      `gorm:"primaryKey"`
+	Topic      string     `gorm:"size:255;not null"`
+	MessageKey []byte     `gorm:"column:message_key"`
+	Value      []byte     `gorm:"not null"`
+	Headers    string     `gorm:"type:jsonb;not null;default:'{}'"`
+	CreatedAt  time.Time  `gorm:"not null"`
+	SentAt     *time.Time `gorm:"index"`
+	Attempts   int        `gorm:"not null;default:0"`
+	LastError  string     `gorm:"type:text"`
+}
@@ -0,0 +1,126 @@
+package outbox
+
+import (
+	"database/sql"
+	"encoding/json"
+	dbRaw "film-rental/pkg/db/raw-sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// relayLockKey is the advisory lock held by the relay publishing a batch. Only one
+// instance relays at a time, which keeps messages in ID order.
+const relayLockKey = 7_311_954_020
+
+// Message is a message to publish once the transaction that enqueued it commits
+type Message struct {
+	Topic   string
+	Key     []byte
+	Value   []byte
+	Headers map[string]string
+}
+
+// Pending is an enqueued message that has not been published yet
+type Pending struct {
+	Message
+	ID        int64
+	CreatedAt time.Time
+	Attempts  int
+}
+
+// Stats describes the backlog of the outbox
+type Stats struct {
+	Pending int64
+	// Lag is the age of the oldest pending message, zero when nothing is pending
+	Lag time.Duration
+}
+
+// Enqueue adds msg to the outbox within tx, so it is published only if tx commits
+func Enqueue(tx *sql.Tx, msg Message, now time.Time) error {
+	headers, err := json.Marshal(msg.Headers)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO outbox_messages (topic, message_key, value, headers, created_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, msg.Topic, msg.Key, msg.Value, string(headers), now)
+	return err
+}
+
+// TryLock takes the relay lock for the duration of tx. It returns false when another
+// instance holds it.
+func TryLock(tx *sql.Tx) (bool, error) {
+	var locked bool
+	err := tx.QueryRow(`SELECT pg_try_advisory_xact_lock($1)`, relayLockKey).Scan(&locked)
+	return locked, err
+}
+
+// FetchPending returns up to limit unsent messages, oldest first
+func FetchPending(tx *sql.Tx, limit int) ([]Pending, error) {
+	rows, err := tx.Query(`
+		SELECT id, topic, message_key, value, headers, created_at, attempts
+		FROM outbox_messages
+		WHERE sent_at IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pending []Pending
+	for rows.Next() {
+		var p Pending
+		var headers string
+		if err := rows.Scan(&p.ID, &p.Topic, &p.Key, &p.Value, &headers, &p.CreatedAt, &p.Attempts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(headers), &p.Headers); err != nil {
+			return nil, err
+		}
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// MarkSent records that the messages were published
+func MarkSent(tx *sql.Tx, ids []int64, now time.Time) error {
+	_, err := tx.Exec(`UPDATE outbox_messages SET sent_at = $1, attempts = attempts + 1, last_error = '' WHERE id = ANY($2)`,
+		now, pq.Array(ids))
+	return err
+}
+
+// MarkFailed records a failed attempt to publish the messages. They stay pending.
+func MarkFailed(tx *sql.Tx, ids []int64, reason string) error {
+	_, err := tx.Exec(`UPDATE outbox_messages SET attempts = attempts + 1, last_error = $1 WHERE id = ANY($2)`,
+		reason, pq.Array(ids))
+	return err
+}
+
+// DeleteSentBefore removes messages published before cutoff and returns how many
+func DeleteSentBefore(cutoff time.Time) (int64, error) {
+	result, err := dbRaw.DB.Exec(`DELETE FROM outbox_messages WHERE sent_at < $1`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// GetStats returns the number of pending messages and how long the oldest has waited
+func GetStats(now time.Time) (Stats, error) {
+	var stats Stats
+	var oldest sql.NullTime
+	err := dbRaw.DB.QueryRow(`SELECT COUNT(*), MIN(created_at) FROM outbox_messages WHERE sent_at IS NULL`).
+		Scan(&stats.Pending, &oldest)
+	if err != nil {
+		return stats, err
+	}
+	if oldest.Valid {
+		stats.Lag = now.Sub(oldest.Time)
+	}
+	return stats, nil
+}
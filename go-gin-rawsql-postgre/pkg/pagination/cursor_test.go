@@ -0,0 +1,26 @@
+package pagination
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{SortBy: "title", Desc: true, Value: "ACADEMY DINOSAUR", ID: 1}
+
+	token := cursor.Encode()
+	require.NotEmpty(t, token)
+
+	decoded, err := DecodeCursor(token)
+	require.NoError(t, err)
+	require.Equal(t, cursor, *decoded)
+}
+
+func TestDecodeCursor_Invalid(t *testing.T) {
+	for _, token := range []string{"not base64!", "bm90IGpzb24", Cursor{}.Encode()} {
+		cursor, err := DecodeCursor(token)
+		require.Equal(t, ErrInvalidCursor, err)
+		require.Nil(t, cursor)
+	}
+}
@@ -1,6 +1,8 @@
 package response
 
 import (
+	"math"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -23,11 +25,41 @@ type MetaResponse struct {
 	Data interface{} `json:"data"`
 }
 
+// PaginationMeta describes a page of results. Offset pagination fills Page and the
+// totals; keyset pagination fills NextCursor and leaves the totals out when the
+// caller asked to skip counting.
 type PaginationMeta struct {
-	Page       int `json:"page"`
-	Limit      int `json:"limit"`
-	TotalCount int `json:"total_count"`
-	TotalPage  int `json:"total_page"`
+	Page       int    `json:"page,omitempty"`
+	Limit      int    `json:"limit"`
+	TotalCount *int   `json:"total_count,omitempty"`
+	TotalPage  *int   `json:"total_page,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPaginationMeta builds the metadata for an offset-paginated page
+func NewPaginationMeta(page int, limit int, totalCount int) PaginationMeta {
+	totalPage := int(math.Ceil(float64(totalCount) / float64(limit)))
+	return PaginationMeta{
+		Page:       page,
+		Limit:      limit,
+		TotalCount: &totalCount,
+		TotalPage:  &totalPage,
+	}
+}
+
+// NewCursorMeta builds the metadata for a keyset-paginated page. totalCount is nil
+// when counting was skipped and nextCursor is empty on the last page.
+func NewCursorMeta(limit int, nextCursor string, totalCount *int) PaginationMeta {
+	meta := PaginationMeta{
+		Limit:      limit,
+		TotalCount: totalCount,
+		NextCursor: nextCursor,
+	}
+	if totalCount != nil {
+		totalPage := int(math.Ceil(float64(*totalCount) / float64(limit)))
+		meta.TotalPage = &totalPage
+	}
+	return meta
 }
 
 func WriteSuccess(c *gin.Context, statusCode int, message string, data interface{}) {
@@ -0,0 +1,19 @@
+// Package scope describes which store's records a request may touch. Repositories
+// take the scope as a plain store ID so the restriction lives in the SQL itself.
+package scope
+
+import "errors"
+
+const (
+	// AllStores is the scope of users allowed to act on every store
+	AllStores = 0
+	// NoStore matches no store; used when a restricted user's store is unknown
+	NoStore = -1
+)
+
+var ErrOutOfScope = errors.New("resource belongs to another store")
+
+// Allows reports whether a record of storeId is visible within storeScope
+func Allows(storeScope int, storeId int) bool {
+	return storeScope == AllStores || storeScope == storeId
+}
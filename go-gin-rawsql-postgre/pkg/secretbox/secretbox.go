@@ -0,0 +1,81 @@
+// Package secretbox encrypts secrets the server stores, such as signing keys, with a
+// server-side AES-256-GCM key so a database dump or backup alone does not reveal them.
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// KeySize is the length of the encryption key in bytes
+const KeySize = 32
+
+var (
+	ErrNotConfigured = errors.New("secret encryption key is not configured")
+	ErrInvalidKey    = fmt.Errorf("secret encryption key must be %d bytes", KeySize)
+	ErrMalformed     = errors.New("sealed secret is malformed or was sealed with another key")
+)
+
+// Box seals and opens secrets with one key
+type Box struct {
+	aead cipher.AEAD
+}
+
+// Default is the box used by the repositories; it is set up in main
+var Default *Box
+
+// New creates a box for a KeySize byte key
+func New(key []byte) (*Box, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Box{aead: aead}, nil
+}
+
+// NewFromEnv creates a box for the base64 encoded SECRET_ENCRYPTION_KEY
+func NewFromEnv() (*Box, error) {
+	encoded := os.Getenv("SECRET_ENCRYPTION_KEY")
+	if encoded == "" {
+		return nil, ErrNotConfigured
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SECRET_ENCRYPTION_KEY: %w", err)
+	}
+	return New(key)
+}
+
+// Seal encrypts plaintext under a random nonce, which is prepended to the result
+func (b *Box) Seal(plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return b.aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts a secret created by Seal
+func (b *Box) Open(sealed []byte) ([]byte, error) {
+	if len(sealed) < b.aead.NonceSize() {
+		return nil, ErrMalformed
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	return plaintext, nil
+}
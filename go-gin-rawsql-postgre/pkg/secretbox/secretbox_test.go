@@ -0,0 +1,62 @@
+package secretbox
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSealOpen(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "JBSWY3DPEHPK3PXP")
+
+	plaintext, err := box.Open(sealed)
+	require.NoError(t, err)
+	require.Equal(t, "JBSWY3DPEHPK3PXP", string(plaintext))
+
+	// A fresh nonce per seal
+	again, err := box.Seal([]byte("JBSWY3DPEHPK3PXP"))
+	require.NoError(t, err)
+	require.NotEqual(t, sealed, again)
+}
+
+func TestOpen_WrongKeyOrTampered(t *testing.T) {
+	box, err := New(bytes.Repeat([]byte{1}, KeySize))
+	require.NoError(t, err)
+	other, err := New(bytes.Repeat([]byte{2}, KeySize))
+	require.NoError(t, err)
+
+	sealed, err := box.Seal([]byte("secret"))
+	require.NoError(t, err)
+
+	_, err = other.Open(sealed)
+	require.Equal(t, ErrMalformed, err)
+
+	sealed[len(sealed)-1] ^= 1
+	_, err = box.Open(sealed)
+	require.Equal(t, ErrMalformed, err)
+
+	_, err = box.Open([]byte("short"))
+	require.Equal(t, ErrMalformed, err)
+}
+
+func TestNew_InvalidKey(t *testing.T) {
+	_, err := New([]byte("too short"))
+	require.Equal(t, ErrInvalidKey, err)
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("SECRET_ENCRYPTION_KEY", "")
+	_, err := NewFromEnv()
+	require.Equal(t, ErrNotConfigured, err)
+
+	t.Setenv("SECRET_ENCRYPTION_KEY", "AQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQEBAQE=")
+	box, err := NewFromEnv()
+	require.NoError(t, err)
+	require.NotNil(t, box)
+}
@@ -1,13 +1,25 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
 )
 
+const (
+	metricsURL       = "http://localhost:9090/metrics"
+	processedMetric  = "kafka_messages_processed"
+	processedTimeout = time.Minute
+)
+
 func main() {
 	broker := "tcp://localhost:1883"
 	topic := "film/mqtt"
@@ -24,13 +36,25 @@ func main() {
 	}
 	defer client.Disconnect(250)
 
+	processedBefore, err := processedCount()
+	if err != nil {
+		panic(err)
+	}
+
+	// Every message carries its own id, which the subscriber forwards as the event id,
+	// so a QoS 1 redelivery is processed once
+	runID := strconv.FormatInt(time.Now().UnixNano(), 36)
+
 	var wg sync.WaitGroup
 	concurrency := 1000 // number of concurrent publishers
 	for i := 0; i < concurrency; i++ {
 		wg.Add(1)
 		go func(id int) {
 			defer wg.Done()
-			msg := fmt.Sprintf("test message %d at %s", id, time.Now().Format(time.RFC3339))
+			msg, _ := json.Marshal(map[string]string{
+				"id":      fmt.Sprintf("%s-%d", runID, id),
+				"message": fmt.Sprintf("test message %d at %s", id, time.Now().Format(time.RFC3339)),
+			})
 			token := client.Publish(topic, 1, false, msg)
 			token.Wait()
 			fmt.Printf("Published: %s\n", msg)
@@ -39,4 +63,47 @@ func main() {
 
 	wg.Wait()
 	fmt.Println("All messages sent")
+
+	// Each published message must be processed exactly once
+	deadline := time.Now().Add(processedTimeout)
+	processed := 0
+	for {
+		count, err := processedCount()
+		if err != nil {
+			panic(err)
+		}
+		processed = count - processedBefore
+		if processed >= concurrency || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	// Give late duplicates a moment to show up
+	time.Sleep(5 * time.Second)
+	if count, err := processedCount(); err == nil {
+		processed = count - processedBefore
+	}
+
+	fmt.Printf("Published %d, processed %d\n", concurrency, processed)
+	if processed != concurrency {
+		os.Exit(1)
+	}
+}
+
+// processedCount reads the kafka_messages_processed count from the metrics endpoint
+func processedCount() (int, error) {
+	resp, err := http.Get(metricsURL)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		name, value, ok := strings.Cut(scanner.Text(), " ")
+		if ok && name == processedMetric {
+			return strconv.Atoi(value)
+		}
+	}
+	return 0, scanner.Err()
 }
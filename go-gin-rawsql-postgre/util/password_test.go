@@ -0,0 +1,24 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestNeedsRehash(t *testing.T) {
+	defer func(cost int) { PasswordCost = cost }(PasswordCost)
+
+	PasswordCost = bcrypt.MinCost
+	hashed, err := HashPassword("Tr0ub4dor&3x")
+	require.NoError(t, err)
+	assert.False(t, NeedsRehash(hashed))
+
+	PasswordCost = bcrypt.MinCost + 1
+	assert.True(t, NeedsRehash(hashed))
+	assert.NoError(t, CheckPassword("Tr0ub4dor&3x", hashed))
+
+	assert.False(t, NeedsRehash("not a bcrypt hash"))
+}
@@ -0,0 +1,111 @@
+package validator
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// breached_passwords.txt holds upper case SHA-1 hashes of common and breached passwords,
+// one per line. Only hashes are shipped, in the same form as the Have I Been Pwned
+// range API, so a larger downloaded list can be swapped in with LoadBreachedPasswords.
+//
+//go:embed breached_passwords.txt
+var bundledBreachedPasswords []byte
+
+const hashPrefixLength = 5
+
+// breachedRanges maps a 5 character hash prefix to the suffixes sharing it, the
+// k-anonymity layout where a lookup only ever reveals the prefix of a password hash
+var (
+	breachedMu     sync.RWMutex
+	breachedRanges map[string]map[string]struct{}
+)
+
+// LoadBreachedPasswords replaces the bundled list with a file of "HASH" or "HASH:COUNT"
+// lines, such as a Have I Been Pwned download
+func LoadBreachedPasswords(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	ranges, err := parseBreachedPasswords(file)
+	if err != nil {
+		return err
+	}
+
+	breachedMu.Lock()
+	breachedRanges = ranges
+	breachedMu.Unlock()
+	return nil
+}
+
+// BreachedRange returns the hash suffixes listed under prefix
+func BreachedRange(prefix string) []string {
+	listed := loadedRanges()[strings.ToUpper(prefix)]
+
+	suffixes := make([]string, 0, len(listed))
+	for suffix := range listed {
+		suffixes = append(suffixes, suffix)
+	}
+	return suffixes
+}
+
+// loadedRanges returns the loaded list, parsing the bundled one on first use
+func loadedRanges() map[string]map[string]struct{} {
+	breachedMu.RLock()
+	ranges := breachedRanges
+	breachedMu.RUnlock()
+	if ranges != nil {
+		return ranges
+	}
+
+	breachedMu.Lock()
+	defer breachedMu.Unlock()
+	if breachedRanges == nil {
+		// The bundled list is embedded, so it always parses
+		breachedRanges, _ = parseBreachedPasswords(bytes.NewReader(bundledBreachedPasswords))
+	}
+	return breachedRanges
+}
+
+// IsBreachedPassword reports whether the password's SHA-1 is in the list
+func IsBreachedPassword(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	for _, suffix := range BreachedRange(hash[:hashPrefixLength]) {
+		if suffix == hash[hashPrefixLength:] {
+			return true
+		}
+	}
+	return false
+}
+
+func parseBreachedPasswords(r io.Reader) (map[string]map[string]struct{}, error) {
+	ranges := make(map[string]map[string]struct{})
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if len(hash) != 2*sha1.Size {
+			continue
+		}
+		hash = strings.ToUpper(hash)
+
+		prefix := hash[:hashPrefixLength]
+		if ranges[prefix] == nil {
+			ranges[prefix] = make(map[string]struct{})
+		}
+		ranges[prefix][hash[hashPrefixLength:]] = struct{}{}
+	}
+	return ranges, scanner.Err()
+}
@@ -0,0 +1,144 @@
+package validator
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// PasswordPolicy is the set of rules new passwords have to follow
+type PasswordPolicy struct {
+	MinLength int
+	// bcrypt ignores everything past 72 bytes
+	MaxLength int
+	// MinClasses is how many of lowercase, uppercase, digits and symbols must appear
+	MinClasses int
+	// HistorySize is how many previous passwords, the current one included, can't be reused
+	HistorySize int
+	// RejectBreached rejects passwords found in the breached password list
+	RejectBreached bool
+}
+
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:      10,
+	MaxLength:      72,
+	MinClasses:     3,
+	HistorySize:    5,
+	RejectBreached: true,
+}
+
+// PasswordPolicyError lists every rule a password broke
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return strings.Join(e.Violations, "; ")
+}
+
+// LoadPasswordPolicy reads PASSWORD_MIN_LENGTH, PASSWORD_MAX_LENGTH,
+// PASSWORD_MIN_CLASSES, PASSWORD_HISTORY and PASSWORD_REJECT_BREACHED, keeping the
+// default for unset variables
+func LoadPasswordPolicy() (PasswordPolicy, error) {
+	policy := DefaultPasswordPolicy
+
+	ints := []struct {
+		name  string
+		value *int
+	}{
+		{"PASSWORD_MIN_LENGTH", &policy.MinLength},
+		{"PASSWORD_MAX_LENGTH", &policy.MaxLength},
+		{"PASSWORD_MIN_CLASSES", &policy.MinClasses},
+		{"PASSWORD_HISTORY", &policy.HistorySize},
+	}
+	for _, setting := range ints {
+		raw := os.Getenv(setting.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.Atoi(raw)
+		if err != nil || value < 0 {
+			return policy, fmt.Errorf("invalid %s: %q", setting.name, raw)
+		}
+		*setting.value = value
+	}
+
+	if raw := os.Getenv("PASSWORD_REJECT_BREACHED"); raw != "" {
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return policy, fmt.Errorf("invalid PASSWORD_REJECT_BREACHED: %q", raw)
+		}
+		policy.RejectBreached = value
+	}
+
+	if policy.MaxLength > 72 || policy.MinLength > policy.MaxLength || policy.MinClasses > 4 {
+		return policy, fmt.Errorf("inconsistent password policy: %+v", policy)
+	}
+	return policy, nil
+}
+
+// Validate checks the rules that only need the password and the username. Reuse of
+// earlier passwords is checked by the caller against the stored hashes.
+func (p PasswordPolicy) Validate(password string, username string) error {
+	var violations []string
+
+	if len(password) < p.MinLength || len(password) > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must contain from %d-%d characters", p.MinLength, p.MaxLength))
+	}
+
+	if classes := characterClasses(password); classes < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("must mix at least %d of lowercase letters, uppercase letters, digits and symbols", p.MinClasses))
+	}
+
+	if username != "" && strings.Contains(strings.ToLower(password), strings.ToLower(username)) {
+		violations = append(violations, "must not contain the username")
+	}
+
+	if p.RejectBreached && IsBreachedPassword(password) {
+		violations = append(violations, "appears in a list of breached passwords")
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+var currentPasswordPolicy = DefaultPasswordPolicy
+
+// SetPasswordPolicy replaces the policy enforced on new passwords. It is meant to be
+// called once at startup.
+func SetPasswordPolicy(policy PasswordPolicy) {
+	currentPasswordPolicy = policy
+}
+
+// CurrentPasswordPolicy returns the policy enforced on new passwords
+func CurrentPasswordPolicy() PasswordPolicy {
+	return currentPasswordPolicy
+}
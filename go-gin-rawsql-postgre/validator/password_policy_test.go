@@ -0,0 +1,73 @@
+package validator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	policy := DefaultPasswordPolicy
+
+	tests := []struct {
+		name       string
+		password   string
+		violations int
+	}{
+		{"Strong password", "Tr0ub4dor&3x", 0},
+		{"Too short", "Ab1!", 1},
+		{"Too few classes", "alllowercaseletters", 1},
+		{"Contains username", "Mike-Rental-2024", 1},
+		{"Breached", "P@ssw0rd123!", 1},
+		{"Short, one class and breached", "password", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.password, "mike")
+			if tt.violations == 0 {
+				assert.NoError(t, err)
+				return
+			}
+
+			var policyErr *PasswordPolicyError
+			require.True(t, errors.As(err, &policyErr))
+			assert.Len(t, policyErr.Violations, tt.violations)
+		})
+	}
+}
+
+func TestLoadPasswordPolicy(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_REJECT_BREACHED", "false")
+
+	policy, err := LoadPasswordPolicy()
+	require.NoError(t, err)
+	assert.Equal(t, 12, policy.MinLength)
+	assert.False(t, policy.RejectBreached)
+	assert.Equal(t, DefaultPasswordPolicy.HistorySize, policy.HistorySize)
+
+	t.Setenv("PASSWORD_MAX_LENGTH", "100")
+	_, err = LoadPasswordPolicy()
+	assert.Error(t, err)
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	defer func() {
+		breachedMu.Lock()
+		breachedRanges = nil
+		breachedMu.Unlock()
+	}()
+
+	// SHA-1 of "correct horse battery staple" in the Have I Been Pwned download format
+	path := filepath.Join(t.TempDir(), "pwned.txt")
+	require.NoError(t, os.WriteFile(path, []byte("ABF7AAD6438836DBE526AA231ABDE2D0EEF74D42:42\n"), 0o600))
+	require.NoError(t, LoadBreachedPasswords(path))
+
+	assert.True(t, IsBreachedPassword("correct horse battery staple"))
+	assert.False(t, IsBreachedPassword("password"))
+}